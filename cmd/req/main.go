@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/adammpkins/req/internal/grammar"
 	"github.com/adammpkins/req/internal/output"
@@ -44,6 +46,7 @@ func main() {
 	}
 
 	flag.Parse()
+	runtime.Version = version
 
 	if *showHelp {
 		flag.Usage()
@@ -205,12 +208,145 @@ func explainCommand(command string) error {
 	return nil
 }
 
+// describeCookie summarizes where and until when a stored cookie is sent.
+func describeCookie(cookie session.Cookie) string {
+	// A leading dot marks a cookie that is also sent to subdomains
+	domain := cookie.Domain
+	if !cookie.HostOnly && domain != "" {
+		domain = "." + domain
+	}
+	parts := []string{domain + cookie.Path}
+	if cookie.Secure {
+		parts = append(parts, "secure")
+	}
+	if cookie.HTTPOnly {
+		parts = append(parts, "httponly")
+	}
+	if cookie.Expires.IsZero() {
+		parts = append(parts, "no expiry")
+	} else {
+		parts = append(parts, "expires "+cookie.Expires.Local().Format(time.RFC3339))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeToken summarizes when a stored OAuth2 token expires and how it is renewed.
+func describeToken(oauth *session.OAuth) string {
+	parts := []string{"from " + oauth.TokenEndpoint}
+	if oauth.ExpiresAt.IsZero() {
+		parts = append(parts, "no expiry")
+	} else {
+		parts = append(parts, "expires "+oauth.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	if _, ok := oauth.RenewalForm(); ok {
+		parts = append(parts, "renewable")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// importCookies stores the cookies of a Netscape cookies.txt file that would be
+// sent to host in the host's session.
+func importCookies(path, host string) error {
+	cookies, err := session.ReadCookieFile(path)
+	if err != nil {
+		return err
+	}
+
+	var matching []session.Cookie
+	for _, cookie := range cookies {
+		if cookie.Matches(hostName(host)) {
+			matching = append(matching, cookie)
+		}
+	}
+	if len(matching) == 0 {
+		return fmt.Errorf("no cookies in %s apply to %s", path, host)
+	}
+
+	sess, err := session.LoadSession(host)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	if sess == nil {
+		sess = &session.Session{Host: host}
+	}
+	sess.AddCookies(matching)
+	if err := session.SaveSession(sess); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s into session for %s\n", cookieCount(len(matching)), host)
+	return nil
+}
+
+// exportCookies writes the cookies of the host's session, or of every stored
+// session, to a Netscape cookies.txt file.
+func exportCookies(path, host string) error {
+	hosts := []string{host}
+	if host == "" {
+		var err error
+		if hosts, err = session.ListSessions(); err != nil {
+			return err
+		}
+	}
+
+	// Collecting into one session drops copies of a domain cookie held by several sessions
+	all := &session.Session{}
+	for _, h := range hosts {
+		sess, err := session.LoadSession(h)
+		if err != nil {
+			return fmt.Errorf("failed to load session: %w", err)
+		}
+		if sess == nil {
+			if host != "" {
+				return fmt.Errorf("no session found for %s", host)
+			}
+			continue
+		}
+		for _, cookie := range sess.Cookies {
+			// Cookies without a domain belong to the session's host
+			if cookie.Domain == "" {
+				cookie.Domain, cookie.HostOnly = hostName(sess.Host), true
+			}
+			all.AddCookies([]session.Cookie{cookie})
+		}
+	}
+
+	if err := session.WriteCookieFile(path, all.Cookies); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %s to %s\n", cookieCount(len(all.Cookies)), path)
+	return nil
+}
+
+// cookieCount formats a number of cookies.
+func cookieCount(n int) string {
+	if n == 1 {
+		return "1 cookie"
+	}
+	return fmt.Sprintf("%d cookies", n)
+}
+
+// hostName returns the host name cookies are matched against for a session host:
+// the host without its port, or localhost for Unix socket sessions.
+func hostName(host string) string {
+	if strings.HasPrefix(host, "unix:") {
+		return "localhost"
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
 // handleSessionCommand handles session management commands.
 func handleSessionCommand(cmd *types.Command) error {
 	host, err := session.ExtractHost(cmd.Target.URL)
 	if err != nil {
 		return fmt.Errorf("invalid host: %w", err)
 	}
+	// Sessions for Unix socket targets are keyed by the socket
+	if cmd.Target.Socket != "" {
+		host = session.SocketHost(cmd.Target.Socket)
+	}
 
 	switch cmd.SessionSubcommand {
 	case "show":
@@ -245,13 +381,16 @@ func handleSessionCommand(cmd *types.Command) error {
 			fmt.Printf("Session for %s:\n", redacted.Host)
 			if len(redacted.Cookies) > 0 {
 				fmt.Println("Cookies:")
-				for name := range redacted.Cookies {
-					fmt.Printf("  %s: ***\n", name)
+				for _, cookie := range redacted.Cookies {
+					fmt.Printf("  %s: *** (%s)\n", cookie.Name, describeCookie(cookie))
 				}
 			}
 			if redacted.Authorization != "" {
 				fmt.Printf("Authorization: %s\n", redacted.Authorization)
 			}
+			if redacted.OAuth != nil {
+				fmt.Printf("Token: %s\n", describeToken(redacted.OAuth))
+			}
 		}
 		return nil
 
@@ -262,6 +401,12 @@ func handleSessionCommand(cmd *types.Command) error {
 		fmt.Printf("Session cleared for %s\n", host)
 		return nil
 
+	case "import-cookies":
+		return importCookies(cmd.SessionFile, host)
+
+	case "export-cookies":
+		return exportCookies(cmd.SessionFile, host)
+
 	case "use":
 		sess, err := session.LoadSession(host)
 		if err != nil {
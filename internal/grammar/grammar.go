@@ -34,21 +34,39 @@ func GetGrammar() Grammar {
 			{Name: "watch", Description: "GET with SSE or polling"},
 			{Name: "inspect", Description: "HEAD only"},
 			{Name: "authenticate", Description: "login and store session state"},
-			{Name: "session", Description: "session management (show, clear, use)"},
+			{Name: "session", Description: "session management (show, clear, use, import-cookies, export-cookies)"},
 		},
 		Clauses: []Clause{
 			{Name: "using=", Description: "HTTP method override", Repeatable: false, Example: "using=PUT"},
 			{Name: "include=", Description: "Add headers, params, cookies, basic auth", Repeatable: true, Example: "include='header: Authorization: Bearer token; param: q=search query; basic: user:pass'"},
 			{Name: "with=", Description: "Request body", Repeatable: false, Example: "with=@user.json or with='{\"name\":\"Adam\"}'"},
 			{Name: "expect=", Description: "Assertions on response", Repeatable: false, Example: "expect=status:200, header:Content-Type=application/json, contains:\"ok\""},
-			{Name: "as=", Description: "Output format for stdout", Repeatable: false, Example: "as=json"},
+			{Name: "as=", Description: "Output format for stdout (json, yaml, ndjson, csv, table, text, raw, envelope)", Repeatable: false, Example: "as=json or as=table"},
 			{Name: "to=", Description: "Destination path", Repeatable: false, Example: "to=out.json"},
-			{Name: "retry=", Description: "Retry attempts for transient errors", Repeatable: false, Example: "retry=3"},
+			{Name: "har=", Description: "Record every request and response to an HTTP Archive (HAR 1.2) file", Repeatable: false, Example: "har=trace.har or har=trace.har:redacted"},
+			{Name: "grant=", Description: "OAuth2 grant for authenticate (client_credentials, with optional :scopes)", Repeatable: false, Example: "grant=client_credentials:read include='basic: id:secret'"},
+			{Name: "cookies=", Description: "Send matching cookies from a Netscape cookies.txt file, without using the stored session", Repeatable: false, Example: "cookies=@cookies.txt"},
+			{Name: "pick=", Description: "Select part of a JSON response (JSONPath)", Repeatable: false, Example: "pick=$.items[?(@.active)].id"},
+			{Name: "columns=", Description: "Select and order columns for as=csv or as=table", Repeatable: false, Example: "columns=id,name,address.city"},
+			{Name: "retry=", Description: "Retry attempts for transient errors", Repeatable: false, Example: "retry=3 or retry=3:always"},
+			{Name: "backoff=", Description: "Retry delay range (exponential with jitter)", Repeatable: false, Example: "backoff=200ms..5s"},
 			{Name: "under=", Description: "Timeout or size limit", Repeatable: false, Example: "under=30s or under=10MB"},
 			{Name: "via=", Description: "Proxy URL", Repeatable: false, Example: "via=http://proxy:8080"},
 			{Name: "attach=", Description: "Multipart parts for upload or send", Repeatable: true, Example: "attach='part: name=avatar, file=@me.png; part: name=meta, value=xyz'"},
 			{Name: "follow=", Description: "Redirect policy for write verbs", Repeatable: false, Example: "follow=smart"},
 			{Name: "insecure=", Description: "Disable TLS verification for this request", Repeatable: false, Example: "insecure=true"},
+			{Name: "http=", Description: "HTTP protocol version: 1.1, 2 (over TLS), or h2c (cleartext HTTP/2)", Repeatable: false, Example: "http=2"},
+			{Name: "socket=", Description: "Dial a Unix socket instead of the URL's host (or use a unix://<socket>:<path> target)", Repeatable: false, Example: "socket=/var/run/docker.sock"},
+			{Name: "cert=", Description: "PEM client certificate for mutual TLS", Repeatable: false, Example: "cert=client.pem"},
+			{Name: "key=", Description: "PEM private key for cert= (passphrase from REQ_KEY_PASSPHRASE)", Repeatable: false, Example: "key=client.key"},
+			{Name: "ca=", Description: "PEM CA bundle file or directory to trust instead of the system roots", Repeatable: false, Example: "ca=internal-ca.pem"},
+			{Name: "servername=", Description: "TLS server name (SNI) override", Repeatable: false, Example: "servername=api.internal"},
+			{Name: "pin=", Description: "Accepted server public key pin (SHA-256 of SPKI); exit 8 on mismatch", Repeatable: true, Example: "pin=sha256//n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="},
+			{Name: "resolve=", Description: "Connect to another address for host:port, keeping Host and SNI", Repeatable: true, Example: "resolve=api.example.com:443:10.0.3.7"},
+			{Name: "ip=", Description: "Force IPv4 or IPv6", Repeatable: false, Example: "ip=4"},
+			{Name: "bind=", Description: "Local address to connect from", Repeatable: false, Example: "bind=10.0.0.2"},
+			{Name: "every=", Description: "Polling interval for watch", Repeatable: false, Example: "every=5s"},
+			{Name: "until=", Description: "Stop watching once a check passes", Repeatable: false, Example: "until=jsonpath:$.state==succeeded"},
 		},
 	}
 }
@@ -98,7 +116,9 @@ func FormatHelp() string {
 	help += "    using=POST \\\n"
 	help += "    with='{\"user\":\"adam\",\"pass\":\"xyz\"}'\n"
 	help += "  \n"
-	help += "  req read https://api.example.com/me as=json\n\n"
+	help += "  req read https://api.example.com/me as=json\n"
+	help += "  \n"
+	help += "  req watch https://api.example.com/jobs/42 every=5s until=jsonpath:$.state==done under=10m\n\n"
 	help += "For more information, see the grammar documentation.\n"
 	
 	return help
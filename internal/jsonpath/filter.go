@@ -0,0 +1,379 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter expressions appear in [?(...)] (the parentheses are optional) and support:
+//
+//	@.path  $.path          paths relative to the current element or the root
+//	'text' "text" 42 -1.5   literals, plus true, false and null
+//	== != < <= > >=         comparisons (ordering applies to numbers and strings)
+//	=~ /regex/i             regular expression match against a string
+//	&& || ! ( )             logic and grouping
+//
+// A bare path such as [?(@.isbn)] tests for existence.
+
+// filterExpr is a node in a parsed filter expression.
+type filterExpr interface {
+	eval(root, current interface{}) interface{}
+}
+
+// nodeList is the result of evaluating a path inside a filter.
+type nodeList []interface{}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(_, _ interface{}) interface{} { return e.value }
+
+type pathExpr struct {
+	relative bool
+	segments []segment
+}
+
+func (e pathExpr) eval(root, current interface{}) interface{} {
+	base := root
+	if e.relative {
+		base = current
+	}
+	return nodeList(evaluate(e.segments, root, base))
+}
+
+type regexExpr struct {
+	re *regexp.Regexp
+}
+
+func (e regexExpr) eval(_, _ interface{}) interface{} { return e.re }
+
+type notExpr struct {
+	x filterExpr
+}
+
+func (e notExpr) eval(root, current interface{}) interface{} {
+	return !truthy(e.x.eval(root, current))
+}
+
+type logicalExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e logicalExpr) eval(root, current interface{}) interface{} {
+	if e.op == "&&" {
+		return truthy(e.left.eval(root, current)) && truthy(e.right.eval(root, current))
+	}
+	return truthy(e.left.eval(root, current)) || truthy(e.right.eval(root, current))
+}
+
+type compareExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e compareExpr) eval(root, current interface{}) interface{} {
+	left, lok := single(e.left.eval(root, current))
+	right, rok := single(e.right.eval(root, current))
+	return Compare(left, lok, e.op, right, rok)
+}
+
+// single unwraps a path result to its only value; empty or multi-value results are "nothing".
+func single(v interface{}) (interface{}, bool) {
+	if nodes, ok := v.(nodeList); ok {
+		if len(nodes) == 1 {
+			return nodes[0], true
+		}
+		return nil, false
+	}
+	return v, true
+}
+
+// truthy reports whether a filter result selects the element.
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nodeList:
+		return len(x) > 0
+	case bool:
+		return x
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// Compare applies a comparison operator to two JSON values. The ok flags mark
+// whether each side exists; two missing values are equal and nothing else is
+// comparable to a missing value. Ordering operators apply only to two numbers
+// or two strings. For =~ the right side is a *regexp.Regexp or a pattern string.
+func Compare(left interface{}, lok bool, op string, right interface{}, rok bool) bool {
+	left, right = number(left), number(right)
+	switch op {
+	case "==":
+		return equal(left, lok, right, rok)
+	case "!=":
+		return !equal(left, lok, right, rok)
+	case "=~":
+		s, ok := left.(string)
+		if !lok || !rok || !ok {
+			return false
+		}
+		switch re := right.(type) {
+		case *regexp.Regexp:
+			return re.MatchString(s)
+		case string:
+			matched, err := regexp.MatchString(re, s)
+			return err == nil && matched
+		}
+		return false
+	}
+
+	if !lok || !rok {
+		return false
+	}
+	if l, ok := left.(float64); ok {
+		if r, ok := right.(float64); ok {
+			return ordered(op, compareNumbers(l, r))
+		}
+	}
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return ordered(op, strings.Compare(l, r))
+		}
+	}
+	return false
+}
+
+// number converts json.Number (from decoders using UseNumber) to float64 for comparison.
+func number(v interface{}) interface{} {
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func equal(left interface{}, lok bool, right interface{}, rok bool) bool {
+	if !lok || !rok {
+		return lok == rok
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func compareNumbers(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func ordered(op string, cmp int) bool {
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// comparisonOps is ordered so two-character operators match before their prefixes.
+var comparisonOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseFilter parses a filter expression up to (not including) the closing bracket.
+func (p *pathParser) parseFilter() (filterExpr, error) {
+	return p.parseOr()
+}
+
+func (p *pathParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.hasPrefix("||") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+}
+
+func (p *pathParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.hasPrefix("&&") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *pathParser) parseUnary() (filterExpr, error) {
+	p.skipSpaces()
+	if p.peek() == '!' && !p.hasPrefix("!=") {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *pathParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	for _, op := range comparisonOps {
+		if !p.hasPrefix(op) {
+			continue
+		}
+		p.pos += len(op)
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *pathParser) parseOperand() (filterExpr, error) {
+	p.skipSpaces()
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return expr, nil
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{relative: c == '@', segments: segments}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case c == '/':
+		return p.parseRegex()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	}
+
+	// Keywords must be whole words: @.x == nullable is not null followed by "able"
+	start := p.pos
+	word := p.parseName()
+	for _, keyword := range filterKeywords {
+		if word == keyword.word {
+			return literalExpr{value: keyword.value}, nil
+		}
+	}
+	p.pos = start
+	if word != "" {
+		return nil, p.errorf("unknown word %q in filter (quote strings)", word)
+	}
+	return nil, p.errorf("expected @, $, literal or ( in filter")
+}
+
+// filterKeywords are the literal words a filter accepts.
+var filterKeywords = []struct {
+	word  string
+	value interface{}
+}{
+	{"true", true},
+	{"false", false},
+	{"null", nil},
+}
+
+func (p *pathParser) parseNumber() (filterExpr, error) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("+-.0123456789eE", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+	}
+	return literalExpr{value: n}, nil
+}
+
+// parseRegex parses /pattern/flags, where flags may include i, m and s.
+func (p *pathParser) parseRegex() (filterExpr, error) {
+	p.pos++ // opening /
+	var pattern strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated regex")
+		}
+		c := p.src[p.pos]
+		if c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/' {
+			pattern.WriteByte('/')
+			p.pos += 2
+			continue
+		}
+		p.pos++
+		if c == '/' {
+			break
+		}
+		pattern.WriteByte(c)
+	}
+
+	flags := ""
+	for p.pos < len(p.src) && strings.IndexByte("ims", p.src[p.pos]) >= 0 {
+		flags += string(p.src[p.pos])
+		p.pos++
+	}
+	expr := pattern.String()
+	if flags != "" {
+		expr = "(?" + flags + ")" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, p.errorf("invalid regex: %v", err)
+	}
+	return regexExpr{re: re}, nil
+}
@@ -0,0 +1,540 @@
+// Package jsonpath implements JSONPath queries over decoded JSON values.
+//
+// Supported syntax:
+//
+//	$                 root
+//	.name ['name']    child (also ["name"] and unions like ['a','b'])
+//	.* [*]            wildcard
+//	..name ..* ..[0]  recursive descent
+//	[0] [-1] [0,2]    array index and index unions
+//	[start:end:step]  array slice
+//	[?(@.price < 10)] filter expression (see filter.go)
+//	.length           size of an array, object or string when no "length" key exists
+//
+// The leading $ is optional, so "items[0].id" and "$.items[0].id" are equivalent.
+//
+// Values are those produced by encoding/json (map[string]interface{}, []interface{},
+// strings, float64 or json.Number, bools and nil), plus any Object implementation
+// for decoders that keep object key order.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Path is a compiled JSONPath expression.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// segment applies its selectors to each input node, or to each input node and
+// all of its descendants when recursive.
+type segment struct {
+	recursive bool
+	selectors []selector
+}
+
+// selector picks zero or more values out of a single node.
+type selector interface {
+	selectFrom(root, node interface{}, out []interface{}) []interface{}
+	singular() bool
+}
+
+// Object is a decoded JSON object that keeps its members in document order.
+// Wildcards, filters and recursive descent visit an Object's keys in that order.
+type Object interface {
+	Keys() []string
+	Get(key string) (interface{}, bool)
+}
+
+// Parse compiles a JSONPath expression.
+func Parse(expr string) (*Path, error) {
+	p := &pathParser{src: strings.TrimSpace(expr)}
+	if p.src == "" {
+		return nil, fmt.Errorf("empty JSONPath expression")
+	}
+
+	if p.peek() == '$' {
+		p.pos++
+	} else if p.peek() != '.' && p.peek() != '[' {
+		// Bare paths like "items[0]" are relative to the root
+		p.src = "." + p.src
+	}
+
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return &Path{expr: expr, segments: segments}, nil
+}
+
+// String returns the source expression.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Singular reports whether the path can select at most one value, i.e. it uses
+// only child names and single indexes (no wildcards, slices, filters, unions or
+// recursive descent).
+func (p *Path) Singular() bool {
+	for _, seg := range p.segments {
+		if seg.recursive || len(seg.selectors) != 1 || !seg.selectors[0].singular() {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate returns every value selected by the path, in document order.
+// Map members are visited in sorted key order, since decoded maps are unordered;
+// Object members in their own order.
+func (p *Path) Evaluate(data interface{}) []interface{} {
+	return evaluate(p.segments, data, data)
+}
+
+// evaluate applies segments starting from node; root is what $ refers to in filters.
+func evaluate(segments []segment, root, node interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, seg := range segments {
+		var next []interface{}
+		for _, n := range nodes {
+			if seg.recursive {
+				for _, d := range descendants(n, nil) {
+					for _, sel := range seg.selectors {
+						next = sel.selectFrom(root, d, next)
+					}
+				}
+				continue
+			}
+			for _, sel := range seg.selectors {
+				next = sel.selectFrom(root, n, next)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// descendants returns node followed by all nested values, depth first.
+func descendants(node interface{}, out []interface{}) []interface{} {
+	out = append(out, node)
+	if keys, get, ok := members(node); ok {
+		for _, key := range keys {
+			value, _ := get(key)
+			out = descendants(value, out)
+		}
+	} else if arr, ok := node.([]interface{}); ok {
+		for _, item := range arr {
+			out = descendants(item, out)
+		}
+	}
+	return out
+}
+
+// members returns an object's keys in visiting order and a lookup function, or
+// ok=false if node is not an object.
+func members(node interface{}) ([]string, func(string) (interface{}, bool), bool) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys, func(key string) (interface{}, bool) {
+			value, ok := v[key]
+			return value, ok
+		}, true
+	case Object:
+		return v.Keys(), v.Get, true
+	}
+	return nil, nil, false
+}
+
+// nameSelector selects an object member by name.
+type nameSelector struct {
+	name string
+}
+
+func (s nameSelector) selectFrom(_, node interface{}, out []interface{}) []interface{} {
+	if keys, get, ok := members(node); ok {
+		if value, ok := get(s.name); ok {
+			return append(out, value)
+		}
+		if s.name == "length" {
+			return append(out, float64(len(keys)))
+		}
+		return out
+	}
+
+	switch v := node.(type) {
+	case []interface{}:
+		if s.name == "length" {
+			return append(out, float64(len(v)))
+		}
+	case string:
+		if s.name == "length" {
+			return append(out, float64(utf8.RuneCountInString(v)))
+		}
+	}
+	return out
+}
+
+func (nameSelector) singular() bool { return true }
+
+// wildcardSelector selects every member of an object or element of an array.
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(_, node interface{}, out []interface{}) []interface{} {
+	if keys, get, ok := members(node); ok {
+		for _, key := range keys {
+			value, _ := get(key)
+			out = append(out, value)
+		}
+	} else if arr, ok := node.([]interface{}); ok {
+		out = append(out, arr...)
+	}
+	return out
+}
+
+func (wildcardSelector) singular() bool { return false }
+
+// indexSelector selects an array element; negative indexes count from the end.
+type indexSelector struct {
+	index int
+}
+
+func (s indexSelector) selectFrom(_, node interface{}, out []interface{}) []interface{} {
+	arr, ok := node.([]interface{})
+	if !ok {
+		return out
+	}
+	i := s.index
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return out
+	}
+	return append(out, arr[i])
+}
+
+func (indexSelector) singular() bool { return true }
+
+// sliceSelector selects a range of array elements like Python slices.
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(_, node interface{}, out []interface{}) []interface{} {
+	arr, ok := node.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+	n := len(arr)
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := 0, n
+		if s.start != nil {
+			start = max(normalize(*s.start), 0)
+		}
+		if s.end != nil {
+			end = min(normalize(*s.end), n)
+		}
+		for i := start; i < end; i += s.step {
+			out = append(out, arr[i])
+		}
+		return out
+	}
+
+	start, end := n-1, -1
+	if s.start != nil {
+		start = min(normalize(*s.start), n-1)
+	}
+	if s.end != nil {
+		end = max(normalize(*s.end), -1)
+	}
+	for i := start; i > end; i += s.step {
+		out = append(out, arr[i])
+	}
+	return out
+}
+
+func (sliceSelector) singular() bool { return false }
+
+// filterSelector selects the members or elements for which the expression holds.
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (s filterSelector) selectFrom(root, node interface{}, out []interface{}) []interface{} {
+	if keys, get, ok := members(node); ok {
+		for _, key := range keys {
+			value, _ := get(key)
+			if truthy(s.expr.eval(root, value)) {
+				out = append(out, value)
+			}
+		}
+	} else if arr, ok := node.([]interface{}); ok {
+		for _, item := range arr {
+			if truthy(s.expr.eval(root, item)) {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func (filterSelector) singular() bool { return false }
+
+// pathParser is a hand-written recursive descent parser shared by paths and filters.
+type pathParser struct {
+	src string
+	pos int
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *pathParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSONPath %q at offset %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseSegments parses segments until the input no longer continues a path.
+func (p *pathParser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for {
+		switch {
+		case p.hasPrefix(".."):
+			p.pos += 2
+			seg, err := p.parseSegmentBody(true)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, seg)
+		case p.peek() == '.':
+			p.pos++
+			seg, err := p.parseSegmentBody(false)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, seg)
+		case p.peek() == '[':
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment{selectors: selectors})
+		default:
+			return segments, nil
+		}
+	}
+}
+
+// parseSegmentBody parses what follows "." or "..": a name, a wildcard, or (after "..") a bracket.
+func (p *pathParser) parseSegmentBody(recursive bool) (segment, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return segment{recursive: recursive, selectors: []selector{wildcardSelector{}}}, nil
+	}
+	if recursive && p.peek() == '[' {
+		selectors, err := p.parseBracket()
+		if err != nil {
+			return segment{}, err
+		}
+		return segment{recursive: true, selectors: selectors}, nil
+	}
+
+	name := p.parseName()
+	if name == "" {
+		return segment{}, p.errorf("expected member name")
+	}
+	return segment{recursive: recursive, selectors: []selector{nameSelector{name: name}}}, nil
+}
+
+// parseName reads a dot-notation member name.
+func (p *pathParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+// parseBracket parses a bracketed selector list: names, indexes, slices, wildcard, or a filter.
+func (p *pathParser) parseBracket() ([]selector, error) {
+	p.pos++ // [
+	p.skipSpaces()
+
+	if p.peek() == '?' {
+		p.pos++
+		expr, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ']' {
+			return nil, p.errorf("expected ] after filter")
+		}
+		p.pos++
+		return []selector{filterSelector{expr: expr}}, nil
+	}
+
+	var selectors []selector
+	for {
+		p.skipSpaces()
+		sel, err := p.parseBracketSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return selectors, nil
+		default:
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *pathParser) parseBracketSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '\'' || c == '"':
+		name, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector{name: name}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	default:
+		return nil, p.errorf("expected name, index, slice or filter")
+	}
+}
+
+// parseIndexOrSlice parses "n" or "start:end:step" with any part optional.
+func (p *pathParser) parseIndexOrSlice() (selector, error) {
+	var parts [3]*int
+	colons := 0
+	for {
+		p.skipSpaces()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			n, err := p.parseInt()
+			if err != nil {
+				return nil, err
+			}
+			parts[colons] = &n
+			p.skipSpaces()
+		}
+		if p.peek() != ':' {
+			break
+		}
+		if colons == 2 {
+			return nil, p.errorf("too many colons in slice")
+		}
+		colons++
+		p.pos++
+	}
+
+	if colons == 0 {
+		if parts[0] == nil {
+			return nil, p.errorf("expected index")
+		}
+		return indexSelector{index: *parts[0]}, nil
+	}
+
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	if step == 0 {
+		return nil, p.errorf("slice step cannot be zero")
+	}
+	return sliceSelector{start: parts[0], end: parts[1], step: step}, nil
+}
+
+func (p *pathParser) parseInt() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("invalid integer %q", p.src[start:p.pos])
+	}
+	return n, nil
+}
+
+// parseQuoted parses a single- or double-quoted string with backslash escapes.
+func (p *pathParser) parseQuoted() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch esc := p.src[p.pos]; esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(esc)
+			}
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
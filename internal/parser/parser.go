@@ -4,29 +4,60 @@
 //
 //	command = verb target [clauses]
 //	verb = "read" | "save" | "send" | "upload" | "watch" | "inspect" | "authenticate" | "session"
-//	target = url
+//	target = url | unix_target
+//	unix_target = "unix://" socket_path [ ":" path ]
 //	clauses = clause { clause }
 //	clause = with_clause | include_clause | attach_clause | expect_clause | as_clause | to_clause |
-//	         using_clause | retry_clause | under_clause | via_clause | follow_clause | insecure_clause
+//	         using_clause | retry_clause | backoff_clause | under_clause | via_clause | follow_clause | insecure_clause |
+//	         http_clause | socket_clause | cert_clause | key_clause | ca_clause | servername_clause | pin_clause |
+//	         resolve_clause | ip_clause | bind_clause | timing_clause | verbose_clause | har_clause | cookies_clause | grant_clause |
+//	         every_clause | until_clause | pick_clause | columns_clause
 //	with_clause = "with=" ( string | "@file" | "@-" )
 //	include_clause = "include=" items
 //	attach_clause = "attach=" parts
 //	expect_clause = "expect=" checks
-//	as_clause = "as=" ( "json" | "csv" | "text" | "raw" )
+//	as_clause = "as=" ( "json" | "yaml" | "ndjson" | "csv" | "table" | "text" | "raw" | "envelope" )
 //	to_clause = "to=" path
 //	using_clause = "using=" ( "GET" | "POST" | "PUT" | "PATCH" | "DELETE" | "HEAD" | "OPTIONS" )
-//	retry_clause = "retry=" number
+//	retry_clause = "retry=" number [ ":always" ]
+//	backoff_clause = "backoff=" duration ".." duration
 //	under_clause = "under=" ( duration | size )
 //	via_clause = "via=" url
 //	follow_clause = "follow=smart"
 //	insecure_clause = "insecure=" ( "true" | "false" )
+//	http_clause = "http=" ( "1.1" | "2" | "h2c" )
+//	socket_clause = "socket=" socket_path
+//	cert_clause = "cert=" path
+//	key_clause = "key=" path
+//	ca_clause = "ca=" path
+//	servername_clause = "servername=" host
+//	pin_clause = "pin=" pin { ";" pin }
+//	pin = "sha256//" base64
+//	resolve_clause = "resolve=" rule { ";" rule }
+//	rule = host ":" port ":" address [ ":" port ]
+//	ip_clause = "ip=" ( "4" | "6" )
+//	bind_clause = "bind=" ip_address
+//	timing_clause = "timing" | "timing=" ( "text" | "json" )
+//	verbose_clause = "verbose" | "verbose=unredacted"
+//	har_clause = "har=" path [ ":redacted" ]
+//	cookies_clause = "cookies=@" path
+//	grant_clause = "grant=client_credentials" [ ":" scope { ( "," | " " ) scope } ]
+//	every_clause = "every=" duration
+//	pick_clause = "pick=" jsonpath
+//	columns_clause = "columns=" key { "," key }
+//	until_clause = "until=" check
 package parser
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/adammpkins/req/internal/jsonpath"
 	"github.com/adammpkins/req/internal/types"
 )
 
@@ -251,7 +282,10 @@ func tokenizeRespectingQuotes(s string) []string {
 	return parts
 }
 
-// looksLikeNewClause checks if a string looks like it starts a new clause (word=)
+// clauseKeys lists every clause key accepted by parseClause.
+var clauseKeys = []string{"with", "include", "attach", "expect", "headers", "params", "as", "to", "using", "retry", "backoff", "timeout", "under", "proxy", "via", "follow", "insecure", "http", "socket", "cert", "key", "ca", "servername", "pin", "resolve", "ip", "bind", "timing", "verbose", "har", "cookies", "grant", "pick", "columns", "every", "until", "field"}
+
+// looksLikeNewClause checks if a string looks like it starts a new clause (word= or a flag)
 func looksLikeNewClause(s string) bool {
 	// Find first space or equals
 	for i, r := range s {
@@ -260,8 +294,7 @@ func looksLikeNewClause(s string) bool {
 			if i > 0 {
 				word := strings.TrimSpace(s[:i])
 				// Check if it's a valid clause key
-				validKeys := []string{"include", "expect", "with", "as", "to", "using", "retry", "under", "via", "follow", "insecure", "attach"}
-				for _, key := range validKeys {
+				for _, key := range clauseKeys {
 					if word == key {
 						return true
 					}
@@ -270,11 +303,11 @@ func looksLikeNewClause(s string) bool {
 			return false
 		}
 		if r == ' ' || r == '\t' {
-			// Found space before = - not a new clause
-			return false
+			// Found space before = - only a flag word starts a new clause
+			return isFlag(s[:i])
 		}
 	}
-	return false
+	return isFlag(s)
 }
 
 // isSimpleWord checks if a string is a simple word (letters, numbers, underscore, no special chars)
@@ -292,7 +325,7 @@ func isSimpleWord(s string) bool {
 
 // looksLikeURL checks if a string looks like a URL.
 func looksLikeURL(s string) bool {
-	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "unix://")
 }
 
 // looksLikeDuration checks if a string looks like a duration.
@@ -303,7 +336,7 @@ func looksLikeDuration(s string) bool {
 
 // isFlag checks if a string is a flag.
 func isFlag(s string) bool {
-	return s == "verbose" || s == "resume"
+	return s == "verbose" || s == "resume" || s == "timing"
 }
 
 // parseCommand parses a command.
@@ -317,19 +350,38 @@ func (p *Parser) parseCommand() (*types.Command, error) {
 	}
 	cmd.Verb = verb
 
-	// Handle session subcommands (show, clear, use)
+	// Handle session subcommands (show, clear, use, import-cookies, export-cookies)
 	if verb == types.VerbSession {
 		if p.pos >= len(p.tokens) {
-			return nil, &ParseError{Position: p.pos, Token: "", Message: "expected session subcommand (show, clear, use)"}
+			return nil, &ParseError{Position: p.pos, Token: "", Message: "expected session subcommand (show, clear, use, import-cookies, export-cookies)"}
 		}
 		tok := p.tokens[p.pos]
 		if tok.typ == tokenWord {
 			subcmd := tok.value
-			if subcmd == "show" || subcmd == "clear" || subcmd == "use" {
+			switch subcmd {
+			case "show", "clear", "use":
 				cmd.SessionSubcommand = subcmd
 				p.pos++
-			} else {
-				return nil, &ParseError{Position: tok.pos, Token: subcmd, Message: "unknown session subcommand (expected show, clear, or use)"}
+			case "import-cookies", "export-cookies":
+				cmd.SessionSubcommand = subcmd
+				p.pos++
+				// The cookies.txt file comes first, then the host. Export may omit
+				// the host to cover every session; import needs it, because
+				// sessions are keyed by host and port, which cookies.txt lacks
+				fileTok := p.tokens[p.pos]
+				if fileTok.typ != tokenWord && fileTok.typ != tokenString {
+					return nil, &ParseError{Position: fileTok.pos, Token: fileTok.value, Message: "expected cookies.txt file path", Suggest: "req session " + subcmd + " cookies.txt api.example.com"}
+				}
+				cmd.SessionFile = unquoteString(fileTok.value)
+				p.pos++
+				if eof := p.tokens[p.pos]; eof.typ == tokenEOF {
+					if subcmd == "import-cookies" {
+						return nil, &ParseError{Position: eof.pos, Token: "", Message: "expected the host whose session receives the cookies", Suggest: "req session import-cookies " + cmd.SessionFile + " api.example.com"}
+					}
+					return cmd, nil
+				}
+			default:
+				return nil, &ParseError{Position: tok.pos, Token: subcmd, Message: "unknown session subcommand (expected show, clear, use, import-cookies, or export-cookies)"}
 			}
 		}
 	}
@@ -450,6 +502,9 @@ func (p *Parser) parseTarget() (types.Target, error) {
 	// For session commands, target might be a host instead of full URL
 	if tok.typ == tokenURL {
 		p.pos++
+		if strings.HasPrefix(tok.value, "unix://") {
+			return parseUnixTarget(tok)
+		}
 		return types.Target{URL: tok.value}, nil
 	} else if tok.typ == tokenWord {
 		// Might be a host name for session commands
@@ -465,6 +520,23 @@ func (p *Parser) parseTarget() (types.Target, error) {
 	return types.Target{}, &ParseError{Position: tok.pos, Token: tok.value, Message: "expected URL or host"}
 }
 
+// parseUnixTarget splits a "unix://<socket>[:<path>]" target into the socket to
+// dial and an http://localhost URL carrying the request path and query.
+func parseUnixTarget(tok token) (types.Target, error) {
+	rest := strings.TrimPrefix(tok.value, "unix://")
+	socket, path := rest, "/"
+	if i := strings.Index(rest, ":"); i >= 0 {
+		socket, path = rest[:i], rest[i+1:]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	if socket == "" {
+		return types.Target{}, &ParseError{Position: tok.pos, Token: tok.value, Message: "expected socket path after unix://", Suggest: "unix:///var/run/docker.sock:/containers/json"}
+	}
+	return types.Target{URL: "http://localhost" + path, Socket: socket}, nil
+}
+
 // parseClauses parses zero or more clauses.
 func (p *Parser) parseClauses() ([]types.Clause, error) {
 	var clauses []types.Clause
@@ -521,6 +593,18 @@ func getSingletonKey(clause types.Clause) string {
 		return "via"
 	case types.InsecureClause:
 		return "insecure"
+	case types.HTTPClause:
+		return "http"
+	case types.SocketClause:
+		return "socket"
+	case types.CertClause:
+		return "cert"
+	case types.KeyClause:
+		return "key"
+	case types.CAClause:
+		return "ca"
+	case types.ServerNameClause:
+		return "servername"
 	case types.FollowClause:
 		return "follow"
 	case types.TimeoutClause:
@@ -529,6 +613,8 @@ func getSingletonKey(clause types.Clause) string {
 		return "backoff"
 	case types.PickClause:
 		return "pick"
+	case types.ColumnsClause:
+		return "columns"
 	case types.EveryClause:
 		return "every"
 	case types.UntilClause:
@@ -541,8 +627,20 @@ func getSingletonKey(clause types.Clause) string {
 		return "verbose"
 	case types.ResumeClause:
 		return "resume"
+	case types.TimingClause:
+		return "timing"
+	case types.HARClause:
+		return "har"
+	case types.CookiesClause:
+		return "cookies"
+	case types.GrantClause:
+		return "grant"
 	// Repeatable clauses return empty string
-	case types.IncludeClause, types.AttachClause:
+	case types.IPClause:
+		return "ip"
+	case types.BindClause:
+		return "bind"
+	case types.IncludeClause, types.AttachClause, types.PinClause, types.ResolveClause:
 		return ""
 	default:
 		return ""
@@ -565,6 +663,8 @@ func (p *Parser) parseClause() (types.Clause, error) {
 			return types.VerboseClause{}, nil
 		case "resume":
 			return types.ResumeClause{}, nil
+		case "timing":
+			return types.TimingClause{Format: "text"}, nil
 		}
 	}
 
@@ -608,8 +708,36 @@ func (p *Parser) parseClause() (types.Clause, error) {
 			return p.parseFollowClause()
 		case "insecure":
 			return p.parseInsecureClause()
+		case "http":
+			return p.parseHTTPClause()
+		case "socket":
+			return p.parseSocketClause()
+		case "cert", "key", "ca":
+			return p.parseTLSFileClause(key)
+		case "servername":
+			return p.parseServerNameClause()
+		case "pin":
+			return p.parsePinClause()
+		case "resolve":
+			return p.parseResolveClause()
+		case "ip":
+			return p.parseIPClause()
+		case "bind":
+			return p.parseBindClause()
+		case "timing":
+			return p.parseTimingClause()
+		case "verbose":
+			return p.parseVerboseClause()
+		case "har":
+			return p.parseHARClause()
+		case "cookies":
+			return p.parseCookiesClause()
+		case "grant":
+			return p.parseGrantClause()
 		case "pick":
 			return p.parsePickClause()
+		case "columns":
+			return p.parseColumnsClause()
 		case "every":
 			return p.parseEveryClause()
 		case "until":
@@ -627,10 +755,9 @@ func (p *Parser) parseClause() (types.Clause, error) {
 
 // suggestClause suggests a similar clause name.
 func suggestClause(input string) string {
-	clauses := []string{"with", "include", "attach", "expect", "headers", "params", "as", "to", "using", "retry", "backoff", "timeout", "under", "proxy", "via", "follow", "insecure", "pick", "every", "until", "field"}
 	best := ""
 	minDist := 999
-	for _, c := range clauses {
+	for _, c := range clauseKeys {
 		dist := levenshteinDistance(input, c)
 		if dist < minDist {
 			minDist = dist
@@ -675,8 +802,8 @@ func (p *Parser) parseWithClause() (types.Clause, error) {
 		if tok.typ == tokenEOF {
 			break
 		}
-		// Stop if we hit another clause (word followed by =)
-		if tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals {
+		// Stop if we hit another clause (word followed by =) or a flag
+		if tok.typ == tokenFlag || (tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals) {
 			break
 		}
 		valueParts = append(valueParts, tok.value)
@@ -768,45 +895,55 @@ func (p *Parser) parseUsingClause() (types.Clause, error) {
 }
 
 // parseRetryClause parses a "retry=" clause.
+// Format: retry=3, or retry=3:always to also retry non-idempotent methods.
 func (p *Parser) parseRetryClause() (types.Clause, error) {
-	if p.pos >= len(p.tokens) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
 		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected retry count"}
 	}
 
 	tok := p.tokens[p.pos]
 	p.pos++
-	// Parse number (simplified)
-	count := 3 // default
-	if tok.typ == tokenNumber {
-		// In a real implementation, parse the number
-		// For now, just use default
+	count, err := strconv.Atoi(tok.value)
+	if err != nil || count < 0 {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "retry count must be a non-negative integer"}
+	}
+
+	clause := types.RetryClause{Count: count}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].typ == tokenColon {
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].value != "always" {
+			return nil, &ParseError{Position: p.pos, Token: p.tokens[p.pos].value, Message: "expected always after retry count"}
+		}
+		p.pos++
+		clause.AllowNonIdempotent = true
 	}
-	return types.RetryClause{Count: count}, nil
+	return clause, nil
 }
 
 // parseBackoffClause parses a "backoff=" clause.
 func (p *Parser) parseBackoffClause() (types.Clause, error) {
 	// Format: backoff=200ms..5s
-	if p.pos+2 >= len(p.tokens) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
 		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected backoff range"}
 	}
 
-	minTok := p.tokens[p.pos]
+	tok := p.tokens[p.pos]
 	p.pos++
-	if p.tokens[p.pos].typ != tokenDotDot {
-		return nil, &ParseError{Position: p.pos, Token: p.tokens[p.pos].value, Message: "expected .."}
+	minStr, maxStr, ok := strings.Cut(tok.value, "..")
+	if !ok {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "expected backoff range like 200ms..5s"}
 	}
-	p.pos++
-	maxTok := p.tokens[p.pos]
-	p.pos++
 
-	minDur, err := parseDuration(minTok.value)
+	minDur, err := parseDuration(minStr)
 	if err != nil {
-		return nil, &ParseError{Position: minTok.pos, Token: minTok.value, Message: "invalid duration"}
+		return nil, &ParseError{Position: tok.pos, Token: minStr, Message: "invalid duration"}
 	}
-	maxDur, err := parseDuration(maxTok.value)
+	maxDur, err := parseDuration(maxStr)
 	if err != nil {
-		return nil, &ParseError{Position: maxTok.pos, Token: maxTok.value, Message: "invalid duration"}
+		return nil, &ParseError{Position: tok.pos, Token: maxStr, Message: "invalid duration"}
+	}
+	if minDur <= 0 || maxDur < minDur {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "backoff range must satisfy 0 < min <= max"}
 	}
 
 	return types.BackoffClause{Min: minDur, Max: maxDur}, nil
@@ -851,7 +988,33 @@ func (p *Parser) parsePickClause() (types.Clause, error) {
 
 	tok := p.tokens[p.pos]
 	p.pos++
-	return types.PickClause{Path: tok.value}, nil
+	path := unquoteString(tok.value)
+	if _, err := jsonpath.Parse(path); err != nil {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: err.Error()}
+	}
+	return types.PickClause{Path: path}, nil
+}
+
+// parseColumnsClause parses a "columns=" clause: a comma-separated list of keys.
+func (p *Parser) parseColumnsClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected column list"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+	var columns []string
+	for _, column := range splitRespectingQuotes(unquoteString(tok.value), ',') {
+		column = unquoteString(strings.TrimSpace(column))
+		if column == "" {
+			return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "empty column name"}
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "expected column list"}
+	}
+	return types.ColumnsClause{Columns: columns}, nil
 }
 
 // parseEveryClause parses an "every=" clause.
@@ -870,14 +1033,43 @@ func (p *Parser) parseEveryClause() (types.Clause, error) {
 }
 
 // parseUntilClause parses an "until=" clause.
+// The predicate uses expect= check syntax: until=status:200, until=contains:"done",
+// or until=jsonpath:$.state==succeeded
 func (p *Parser) parseUntilClause() (types.Clause, error) {
-	if p.pos >= len(p.tokens) {
+	// Collect tokens until the next clause, joining typed values like status:200
+	var valueParts []string
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if tok.typ == tokenEOF {
+			break
+		}
+		// Stop if we hit another clause (word followed by =) or a flag
+		if tok.typ == tokenFlag || (tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals) {
+			break
+		}
+		valueParts = append(valueParts, tok.value)
+		p.pos++
+	}
+
+	if len(valueParts) == 0 {
 		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected predicate"}
 	}
 
-	tok := p.tokens[p.pos]
-	p.pos++
-	return types.UntilClause{Predicate: tok.value}, nil
+	value := ""
+	for i, part := range valueParts {
+		if i > 0 && valueParts[i-1] != ":" && part != ":" {
+			value += " "
+		}
+		value += part
+	}
+	value = unquoteString(value)
+
+	check, err := parseExpectCheck(value)
+	if err != nil {
+		return nil, &ParseError{Position: p.pos, Token: value, Message: err.Error()}
+	}
+
+	return types.UntilClause{Predicate: value, Check: check}, nil
 }
 
 // parseFieldClause parses a "field=" clause.
@@ -912,8 +1104,8 @@ func (p *Parser) parseIncludeClause() (types.Clause, error) {
 		if tok.typ == tokenEOF {
 			break
 		}
-		// Stop if we hit another clause (word followed by =)
-		if tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals {
+		// Stop if we hit another clause (word followed by =) or a flag
+		if tok.typ == tokenFlag || (tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals) {
 			break
 		}
 		valueParts = append(valueParts, tok.value)
@@ -1165,8 +1357,8 @@ func (p *Parser) parseAttachClause() (types.Clause, error) {
 		if tok.typ == tokenEOF {
 			break
 		}
-		// Stop if we hit another clause (word followed by =)
-		if tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals {
+		// Stop if we hit another clause (word followed by =) or a flag
+		if tok.typ == tokenFlag || (tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals) {
 			break
 		}
 		valueParts = append(valueParts, tok.value)
@@ -1306,8 +1498,8 @@ func (p *Parser) parseExpectClause() (types.Clause, error) {
 		if tok.typ == tokenEOF {
 			break
 		}
-		// Stop if we hit another clause (word followed by =)
-		if tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals {
+		// Stop if we hit another clause (word followed by =) or a flag
+		if tok.typ == tokenFlag || (tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].typ == tokenEquals) {
 			break
 		}
 		valueParts = append(valueParts, tok.value)
@@ -1395,8 +1587,7 @@ func parseExpectCheck(part string) (types.ExpectCheck, error) {
 	
 	if strings.HasPrefix(unquoted, "jsonpath:") {
 		value := strings.TrimSpace(strings.TrimPrefix(unquoted, "jsonpath:"))
-		value = unquoteString(value)
-		return types.ExpectCheck{Type: "jsonpath", Path: value}, nil
+		return parseJSONPathCheck(unquoteString(value))
 	}
 	
 	if strings.HasPrefix(unquoted, "matches:") {
@@ -1408,6 +1599,115 @@ func parseExpectCheck(part string) (types.ExpectCheck, error) {
 	return types.ExpectCheck{}, fmt.Errorf("unknown expect check type: %s", part)
 }
 
+// jsonPathCheckOps is ordered so two-character operators match before their prefixes.
+var jsonPathCheckOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseJSONPathCheck parses "<path>", "<path> exists" or "<path> <op> <literal>".
+// Operators inside brackets belong to filter expressions, not the check.
+func parseJSONPathCheck(value string) (types.ExpectCheck, error) {
+	check := types.ExpectCheck{Type: "jsonpath", Op: "exists"}
+
+	path, literal := value, ""
+	if idx, op := findJSONPathCheckOp(value); idx != -1 {
+		path, literal = value[:idx], strings.TrimSpace(value[idx+len(op):])
+		if literal == "" {
+			return types.ExpectCheck{}, fmt.Errorf("jsonpath check %q is missing a value after %s", value, op)
+		}
+		check.Op = op
+		check.Value = unquoteString(literal)
+	} else if rest, ok := strings.CutSuffix(value, " exists"); ok {
+		path = rest
+	}
+	check.Path = strings.TrimSpace(path)
+
+	if _, err := jsonpath.Parse(check.Path); err != nil {
+		return types.ExpectCheck{}, fmt.Errorf("invalid jsonpath check %q: %v", value, err)
+	}
+
+	switch check.Op {
+	case "exists":
+	case "=~":
+		pattern := regexLiteral(check.Value)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return types.ExpectCheck{}, fmt.Errorf("invalid regex in jsonpath check %q: %v", value, err)
+		}
+		check.Expected = pattern
+	default:
+		check.Expected = parseCheckLiteral(literal)
+	}
+	return check, nil
+}
+
+// findJSONPathCheckOp returns the position of the first comparison operator that is
+// outside brackets, parentheses and quotes, or -1 if there is none.
+func findJSONPathCheckOp(value string) (int, string) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0:
+			for _, op := range jsonPathCheckOps {
+				if strings.HasPrefix(value[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parseCheckLiteral types the right-hand side of a jsonpath comparison. Quoted text
+// is always a string; otherwise true, false, null and numbers take their JSON types
+// and anything else is a bare string.
+func parseCheckLiteral(text string) interface{} {
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		return unquoteString(text)
+	}
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return n
+	}
+	return text
+}
+
+// regexLiteral converts /pattern/flags to Go regexp syntax; other text is used as-is.
+func regexLiteral(text string) string {
+	end := strings.LastIndex(text, "/")
+	if len(text) < 2 || text[0] != '/' || end == 0 {
+		return text
+	}
+	flags := text[end+1:]
+	if strings.Trim(flags, "ims") != "" {
+		return text
+	}
+	pattern := text[1:end]
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return pattern
+}
+
 // parseFollowClause parses a "follow=" clause.
 func (p *Parser) parseFollowClause() (types.Clause, error) {
 	if p.pos >= len(p.tokens) {
@@ -1454,22 +1754,25 @@ func parseSize(s string) (int64, error) {
 	s = strings.TrimSpace(s)
 	s = strings.ToUpper(s)
 	
-	multipliers := map[string]int64{
-		"B":  1,
-		"KB": 1024,
-		"MB": 1024 * 1024,
-		"GB": 1024 * 1024 * 1024,
-		"TB": 1024 * 1024 * 1024 * 1024,
-	}
-	
-	for suffix, mult := range multipliers {
-		if strings.HasSuffix(s, suffix) {
-			numStr := strings.TrimSuffix(s, suffix)
-			var num float64
-			if _, err := fmt.Sscanf(numStr, "%f", &num); err != nil {
-				return 0, err
+	// Longest suffixes first so "10KB" is not read as "10K" bytes
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"TB", 1024 * 1024 * 1024 * 1024},
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"B", 1},
+	}
+
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			num, err := strconv.ParseFloat(strings.TrimSuffix(s, m.suffix), 64)
+			if err != nil || num < 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
 			}
-			return int64(num * float64(mult)), nil
+			return int64(num * float64(m.mult)), nil
 		}
 	}
 	
@@ -1503,3 +1806,302 @@ func (p *Parser) parseInsecureClause() (types.Clause, error) {
 	
 	return types.InsecureClause{Value: value == "true"}, nil
 }
+
+// parseSocketClause parses a "socket=" clause.
+func (p *Parser) parseSocketClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected socket path"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	path := unquoteString(strings.TrimSpace(tok.value))
+	if path == "" {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "expected socket path"}
+	}
+	return types.SocketClause{Path: path}, nil
+}
+
+// parseTLSFileClause parses a "cert=", "key=" or "ca=" clause, which each name a file.
+func (p *Parser) parseTLSFileClause(key string) (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: fmt.Sprintf("expected %s path", key)}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	path := strings.TrimPrefix(unquoteString(strings.TrimSpace(tok.value)), "@")
+	if path == "" {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: fmt.Sprintf("expected %s path", key)}
+	}
+	switch key {
+	case "cert":
+		return types.CertClause{Path: path}, nil
+	case "key":
+		return types.KeyClause{Path: path}, nil
+	}
+	return types.CAClause{Path: path}, nil
+}
+
+// parseServerNameClause parses a "servername=" clause.
+func (p *Parser) parseServerNameClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected server name"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	name := unquoteString(strings.TrimSpace(tok.value))
+	if name == "" || strings.ContainsAny(name, "/: ") {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "servername expects a host name without scheme or port"}
+	}
+	return types.ServerNameClause{Name: name}, nil
+}
+
+// parsePinClause parses a "pin=" clause of one or more ";"-separated
+// "sha256//<base64>" public key pins.
+func (p *Parser) parsePinClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected pin"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	var pins []string
+	for _, pin := range strings.Split(unquoteString(strings.TrimSpace(tok.value)), ";") {
+		pin = strings.TrimSpace(pin)
+		hash, ok := strings.CutPrefix(pin, "sha256//")
+		if !ok {
+			return nil, &ParseError{Position: tok.pos, Token: pin, Message: "pin must start with sha256//", Suggest: "pin=sha256//<base64 SPKI hash>"}
+		}
+		sum, err := base64.StdEncoding.DecodeString(hash)
+		if err != nil || len(sum) != 32 {
+			return nil, &ParseError{Position: tok.pos, Token: pin, Message: "pin must be a base64 SHA-256 hash (44 characters)"}
+		}
+		// Store the canonical encoding so pins compare as strings
+		pins = append(pins, "sha256//"+base64.StdEncoding.EncodeToString(sum))
+	}
+	return types.PinClause{Pins: pins}, nil
+}
+
+// rawClauseValue consumes the current clause's value and returns it as written.
+// Values such as "localhost:8080:127.0.0.1" are split by the tokenizer into a
+// typed word, a colon and the rest, which are joined back together here.
+func (p *Parser) rawClauseValue() (token, string) {
+	tok := p.tokens[p.pos]
+	p.pos++
+	value := tok.value
+	if tok.typ == tokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos].typ == tokenColon {
+		value += ":" + p.tokens[p.pos+1].value
+		p.pos += 2
+	}
+	return tok, unquoteString(strings.TrimSpace(value))
+}
+
+// parseResolveClause parses a "resolve=" clause of one or more ";"-separated
+// host:port:address[:port] rules, like curl's --resolve and --connect-to.
+func (p *Parser) parseResolveClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected resolve rule"}
+	}
+
+	tok, value := p.rawClauseValue()
+	var rules []types.ResolveRule
+	for _, spec := range strings.Split(value, ";") {
+		rule, err := parseResolveRule(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, &ParseError{Position: tok.pos, Token: spec, Message: err.Error(), Suggest: "resolve=api.example.com:443:10.0.0.5"}
+		}
+		rules = append(rules, rule)
+	}
+	return types.ResolveClause{Rules: rules}, nil
+}
+
+// parseResolveRule parses host:port:address[:port]. IPv6 addresses may be
+// bracketed, and must be when followed by a port.
+func parseResolveRule(spec string) (types.ResolveRule, error) {
+	host, rest, err := cutHost(spec)
+	if err != nil {
+		return types.ResolveRule{}, err
+	}
+	port, target, ok := strings.Cut(rest, ":")
+	if !ok || !isPort(port) {
+		return types.ResolveRule{}, fmt.Errorf("resolve rule must be host:port:address")
+	}
+
+	rule := types.ResolveRule{Host: strings.ToLower(host), Port: port}
+	switch {
+	case target == "":
+		return types.ResolveRule{}, fmt.Errorf("resolve rule is missing the address")
+	case net.ParseIP(strings.Trim(target, "[]")) != nil:
+		// A bare or bracketed IP with no port
+		rule.Address = strings.Trim(target, "[]")
+	default:
+		addr, toPort, err := net.SplitHostPort(target)
+		if err != nil {
+			if strings.ContainsAny(target, ":[]") {
+				return types.ResolveRule{}, fmt.Errorf("invalid resolve address %q", target)
+			}
+			addr = target // a host name with no port
+		} else if !isPort(toPort) {
+			return types.ResolveRule{}, fmt.Errorf("invalid resolve port %q", toPort)
+		}
+		rule.Address, rule.ToPort = addr, toPort
+	}
+	return rule, nil
+}
+
+// cutHost splits a leading host, which may be a bracketed IPv6 address, from the
+// ":"-separated remainder.
+func cutHost(spec string) (string, string, error) {
+	if strings.HasPrefix(spec, "[") {
+		end := strings.Index(spec, "]:")
+		if end < 0 {
+			return "", "", fmt.Errorf("resolve rule must be host:port:address")
+		}
+		return spec[1:end], spec[end+2:], nil
+	}
+	host, rest, ok := strings.Cut(spec, ":")
+	if !ok || host == "" {
+		return "", "", fmt.Errorf("resolve rule must be host:port:address")
+	}
+	return host, rest, nil
+}
+
+// isPort reports whether s is a TCP port number.
+func isPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// parseIPClause parses an "ip=" clause.
+func (p *Parser) parseIPClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected ip version"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	version := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(tok.value)), "v")
+	if version != "4" && version != "6" {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "ip accepts only '4' or '6'"}
+	}
+	return types.IPClause{Version: version}, nil
+}
+
+// parseBindClause parses a "bind=" clause.
+func (p *Parser) parseBindClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected local address"}
+	}
+
+	tok, value := p.rawClauseValue()
+	addr := strings.Trim(value, "[]")
+	if net.ParseIP(addr) == nil {
+		return nil, &ParseError{Position: tok.pos, Token: value, Message: "bind expects a local IP address"}
+	}
+	return types.BindClause{Address: addr}, nil
+}
+
+// parseTimingClause parses a "timing=" clause.
+func (p *Parser) parseTimingClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected timing format"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	format := strings.ToLower(strings.TrimSpace(tok.value))
+	if format != "text" && format != "json" {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "timing accepts only 'text' or 'json'"}
+	}
+	return types.TimingClause{Format: format}, nil
+}
+
+// parseVerboseClause parses a "verbose=" clause.
+func (p *Parser) parseVerboseClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected 'unredacted'"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	if strings.ToLower(strings.TrimSpace(tok.value)) != "unredacted" {
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "verbose accepts only 'unredacted'; use the bare verbose flag for redacted output"}
+	}
+	return types.VerboseClause{ShowCredentials: true}, nil
+}
+
+// parseHARClause parses a "har=" clause: a file path, optionally followed by
+// ":redacted" to hide credentials.
+func (p *Parser) parseHARClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected HAR file path"}
+	}
+
+	tok, value := p.rawClauseValue()
+	path, redact := strings.CutSuffix(value, ":redacted")
+	if path == "" {
+		return nil, &ParseError{Position: tok.pos, Token: value, Message: "expected HAR file path"}
+	}
+	return types.HARClause{Path: path, Redact: redact}, nil
+}
+
+// parseCookiesClause parses a "cookies=@file" clause naming a Netscape cookies.txt file.
+func (p *Parser) parseCookiesClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected cookie file", Suggest: "cookies=@cookies.txt"}
+	}
+
+	tok, value := p.rawClauseValue()
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok || path == "" || path == "-" {
+		return nil, &ParseError{Position: tok.pos, Token: value, Message: "cookies takes a cookies.txt file as @path; use include='cookie: name=value' for single cookies", Suggest: "cookies=@cookies.txt"}
+	}
+	return types.CookiesClause{Path: path}, nil
+}
+
+// parseGrantClause parses a "grant=" clause: an OAuth2 grant type, optionally
+// followed by ":" and the scopes to request.
+func (p *Parser) parseGrantClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ == tokenEOF {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected grant type", Suggest: "grant=client_credentials"}
+	}
+
+	tok, value := p.rawClauseValue()
+	grantType, scope, _ := strings.Cut(value, ":")
+	if grantType != "client_credentials" {
+		return nil, &ParseError{Position: tok.pos, Token: value, Message: "grant accepts only 'client_credentials'; other flows send their own body with with=", Suggest: "grant=client_credentials"}
+	}
+	// Scopes may be separated by commas so they need no quoting; OAuth2 wants spaces
+	scopes := strings.FieldsFunc(scope, func(r rune) bool { return r == ',' || r == ' ' })
+	return types.GrantClause{Type: grantType, Scope: strings.Join(scopes, " ")}, nil
+}
+
+// parseHTTPClause parses an "http=" clause.
+func (p *Parser) parseHTTPClause() (types.Clause, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, &ParseError{Position: p.pos, Token: "", Message: "expected http version"}
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	version := strings.ToLower(strings.TrimSpace(tok.value))
+	switch version {
+	case "1.1", "2", "h2c":
+		return types.HTTPClause{Version: version}, nil
+	case "1", "http/1.1":
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "http accepts only '1.1', '2' or 'h2c'", Suggest: "http=1.1"}
+	case "h2", "2.0", "http/2":
+		return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "http accepts only '1.1', '2' or 'h2c'", Suggest: "http=2"}
+	}
+	return nil, &ParseError{Position: tok.pos, Token: tok.value, Message: "http accepts only '1.1', '2' or 'h2c'"}
+}
@@ -4,6 +4,7 @@ package planner
 import (
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -19,9 +20,11 @@ type ExecutionPlan struct {
 	Verb        types.Verb         `json:"verb"`
 	Method      string             `json:"method"`
 	URL         string             `json:"url"`
+	Socket      string             `json:"socket,omitempty"` // Unix socket dialed instead of the URL's host
 	Headers     map[string]string  `json:"headers,omitempty"`
 	QueryParams map[string]string  `json:"query_params,omitempty"`
 	Cookies     map[string]string  `json:"cookies,omitempty"`
+	CookieFile  string             `json:"cookie_file,omitempty"` // Netscape cookies.txt sent with this request only
 	Body        *BodyPlan          `json:"body,omitempty"`
 	Output      *OutputPlan        `json:"output,omitempty"`
 	Retry       *RetryPlan         `json:"retry,omitempty"`
@@ -29,10 +32,42 @@ type ExecutionPlan struct {
 	SizeLimit   *int64            `json:"size_limit,omitempty"`
 	Proxy       string             `json:"proxy,omitempty"`
 	Insecure    bool               `json:"insecure,omitempty"`
+	HTTPVersion string             `json:"http_version,omitempty"` // "1.1", "2", "h2c", or empty for the default
+	TLS         *TLSPlan           `json:"tls,omitempty"`
+	Network     *NetworkPlan       `json:"network,omitempty"`
 	Verbose     bool               `json:"verbose,omitempty"`
+	Unredacted  bool               `json:"unredacted,omitempty"` // verbose shows Authorization and cookie values
+	HAR         *HARPlan           `json:"har,omitempty"`
+	Grant       *GrantPlan         `json:"grant,omitempty"`
+	Timing      string             `json:"timing,omitempty"` // "text" or "json" to report per-hop timings
 	Resume      bool               `json:"resume,omitempty"`
 	Follow      string             `json:"follow,omitempty"` // "smart" or empty
 	Expect      []types.ExpectCheck `json:"expect,omitempty"`
+	Poll        *PollPlan          `json:"poll,omitempty"`
+}
+
+// TLSPlan represents client certificate, trust and server name settings. It holds
+// file paths only; key material and passphrases are loaded at execution time.
+type TLSPlan struct {
+	CertFile   string `json:"cert_file,omitempty"`
+	KeyFile    string `json:"key_file,omitempty"` // defaults to the key in CertFile
+	CAPath     string `json:"ca_path,omitempty"`  // PEM bundle file or directory; replaces the system roots
+	ServerName string   `json:"server_name,omitempty"`
+	Pins       []string `json:"pins,omitempty"` // accepted "sha256//" public key pins for the server certificate
+}
+
+// NetworkPlan represents where and how connections are made. None of it changes
+// the URL, so the Host header, SNI and certificate checks still use the URL's host.
+type NetworkPlan struct {
+	Resolve      map[string]string `json:"resolve,omitempty"`       // "host:port" to the "address:port" dialed instead
+	IPVersion    string            `json:"ip_version,omitempty"`    // "4" or "6"
+	LocalAddress string            `json:"local_address,omitempty"` // local IP to connect from
+}
+
+// PollPlan represents polling configuration for the watch verb.
+type PollPlan struct {
+	Interval time.Duration      `json:"interval"`
+	Until    *types.ExpectCheck `json:"until,omitempty"` // stop once this check passes
 }
 
 // BodyPlan represents the request body configuration.
@@ -47,17 +82,34 @@ type BodyPlan struct {
 
 // OutputPlan represents the output configuration.
 type OutputPlan struct {
-	Format      string `json:"format"` // json, csv, text, raw
-	Destination string `json:"destination,omitempty"`
-	Pick        string `json:"pick,omitempty"` // JSONPath expression
+	Format      string   `json:"format"` // json, yaml, ndjson, csv, table, text, raw, envelope
+	Destination string   `json:"destination,omitempty"`
+	Pick        string   `json:"pick,omitempty"`    // JSONPath expression
+	Columns     []string `json:"columns,omitempty"` // column selection for csv and table output
+}
+
+// HARPlan represents where to record the exchange as an HTTP Archive.
+type HARPlan struct {
+	Path   string `json:"path"`
+	Redact bool   `json:"redact,omitempty"` // hide Authorization and cookie values
+}
+
+// GrantPlan represents the OAuth2 grant requested by authenticate.
+type GrantPlan struct {
+	Type  string `json:"type"`
+	Scope string `json:"scope,omitempty"`
 }
 
 // RetryPlan represents retry configuration.
 type RetryPlan struct {
-	Count  int           `json:"count"`
-	Backoff BackoffRange `json:"backoff"`
+	Count              int          `json:"count"`
+	Backoff            BackoffRange `json:"backoff"`
+	AllowNonIdempotent bool         `json:"allow_non_idempotent,omitempty"`
 }
 
+// DefaultPollInterval is used when until= is given without every=.
+const DefaultPollInterval = 5 * time.Second
+
 // BackoffRange represents a backoff range with min and max durations.
 type BackoffRange struct {
 	Min time.Duration `json:"min"`
@@ -69,6 +121,7 @@ func Plan(cmd *types.Command) (*ExecutionPlan, error) {
 	plan := &ExecutionPlan{
 		Verb:        cmd.Verb,
 		URL:         cmd.Target.URL,
+		Socket:      cmd.Target.Socket,
 		Headers:     make(map[string]string),
 		QueryParams: make(map[string]string),
 		Cookies:     make(map[string]string),
@@ -107,6 +160,13 @@ func Plan(cmd *types.Command) (*ExecutionPlan, error) {
 		}
 	}
 
+	// Post-process: an OAuth2 grant sends its own token request
+	if plan.Grant != nil {
+		if err := applyGrant(plan); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate plan
 	if err := validatePlan(plan); err != nil {
 		return nil, err
@@ -115,6 +175,25 @@ func Plan(cmd *types.Command) (*ExecutionPlan, error) {
 	return plan, nil
 }
 
+// applyGrant turns the plan into a token request for its OAuth2 grant: a form POST
+// authenticated with the client credentials from include='basic: id:secret'.
+func applyGrant(plan *ExecutionPlan) error {
+	if plan.Body != nil {
+		return fmt.Errorf("grant= builds the token request body and cannot be combined with with= or attach=")
+	}
+	if !strings.HasPrefix(plan.Headers["Authorization"], "Basic ") {
+		return fmt.Errorf("grant=%s needs the client credentials as include='basic: client_id:client_secret'", plan.Grant.Type)
+	}
+
+	form := url.Values{"grant_type": {plan.Grant.Type}}
+	if plan.Grant.Scope != "" {
+		form.Set("scope", plan.Grant.Scope)
+	}
+	plan.Method = http.MethodPost
+	plan.Body = &BodyPlan{Type: "form", Content: form.Encode()}
+	return nil
+}
+
 // applyVerbDefaults applies default settings based on the verb.
 func applyVerbDefaults(verb types.Verb, plan *ExecutionPlan) error {
 	switch verb {
@@ -248,6 +327,7 @@ func applyClause(clause types.Clause, plan *ExecutionPlan, verb types.Verb) erro
 			}
 		}
 		plan.Retry.Count = c.Count
+		plan.Retry.AllowNonIdempotent = c.AllowNonIdempotent
 	case types.BackoffClause:
 		if plan.Retry == nil {
 			plan.Retry = &RetryPlan{Count: 3}
@@ -265,8 +345,55 @@ func applyClause(clause types.Clause, plan *ExecutionPlan, verb types.Verb) erro
 			plan.Output = &OutputPlan{}
 		}
 		plan.Output.Pick = c.Path
+	case types.ColumnsClause:
+		if plan.Output == nil {
+			plan.Output = &OutputPlan{}
+		}
+		plan.Output.Columns = c.Columns
 	case types.InsecureClause:
 		plan.Insecure = c.Value
+	case types.HTTPClause:
+		plan.HTTPVersion = c.Version
+	case types.CertClause:
+		tlsPlan(plan).CertFile = c.Path
+	case types.KeyClause:
+		tlsPlan(plan).KeyFile = c.Path
+	case types.CAClause:
+		tlsPlan(plan).CAPath = c.Path
+	case types.ServerNameClause:
+		tlsPlan(plan).ServerName = c.Name
+	case types.ResolveClause:
+		network := networkPlan(plan)
+		if network.Resolve == nil {
+			network.Resolve = make(map[string]string)
+		}
+		for _, rule := range c.Rules {
+			port := rule.ToPort
+			if port == "" {
+				port = rule.Port
+			}
+			network.Resolve[net.JoinHostPort(rule.Host, rule.Port)] = net.JoinHostPort(rule.Address, port)
+		}
+	case types.IPClause:
+		networkPlan(plan).IPVersion = c.Version
+	case types.BindClause:
+		networkPlan(plan).LocalAddress = c.Address
+	case types.HARClause:
+		plan.HAR = &HARPlan{Path: c.Path, Redact: c.Redact}
+	case types.CookiesClause:
+		plan.CookieFile = c.Path
+	case types.GrantClause:
+		if verb != types.VerbAuthenticate {
+			return fmt.Errorf("grant= is only supported by authenticate")
+		}
+		plan.Grant = &GrantPlan{Type: c.Type, Scope: c.Scope}
+	case types.PinClause:
+		tlsPlan(plan).Pins = append(tlsPlan(plan).Pins, c.Pins...)
+	case types.SocketClause:
+		if plan.Socket != "" {
+			return fmt.Errorf("socket= cannot be combined with a unix:// target")
+		}
+		plan.Socket = c.Path
 	case types.ViaClause:
 		plan.Proxy = c.URL
 	case types.IncludeClause:
@@ -315,14 +442,53 @@ func applyClause(clause types.Clause, plan *ExecutionPlan, verb types.Verb) erro
 		}
 	case types.VerboseClause:
 		plan.Verbose = true
+		plan.Unredacted = c.ShowCredentials
 	case types.ResumeClause:
 		plan.Resume = true
+	case types.TimingClause:
+		plan.Timing = c.Format
+	case types.EveryClause:
+		if verb != types.VerbWatch {
+			return fmt.Errorf("every= is only supported by the watch verb")
+		}
+		if c.Interval <= 0 {
+			return fmt.Errorf("every= interval must be positive")
+		}
+		if plan.Poll == nil {
+			plan.Poll = &PollPlan{}
+		}
+		plan.Poll.Interval = c.Interval
+	case types.UntilClause:
+		if verb != types.VerbWatch {
+			return fmt.Errorf("until= is only supported by the watch verb")
+		}
+		if plan.Poll == nil {
+			plan.Poll = &PollPlan{Interval: DefaultPollInterval}
+		}
+		check := c.Check
+		plan.Poll.Until = &check
 	default:
 		return fmt.Errorf("unsupported clause type: %T", clause)
 	}
 	return nil
 }
 
+// tlsPlan returns the plan's TLS settings, creating them on first use.
+func tlsPlan(plan *ExecutionPlan) *TLSPlan {
+	if plan.TLS == nil {
+		plan.TLS = &TLSPlan{}
+	}
+	return plan.TLS
+}
+
+// networkPlan returns the plan's network settings, creating them on first use.
+func networkPlan(plan *ExecutionPlan) *NetworkPlan {
+	if plan.Network == nil {
+		plan.Network = &NetworkPlan{}
+	}
+	return plan.Network
+}
+
 // validatePlan validates the execution plan.
 func validatePlan(plan *ExecutionPlan) error {
 	if plan.Method == "" {
@@ -331,6 +497,40 @@ func validatePlan(plan *ExecutionPlan) error {
 	if plan.URL == "" {
 		return fmt.Errorf("URL is required")
 	}
+	if plan.Resume && (plan.Output == nil || plan.Output.Destination == "") {
+		return fmt.Errorf("resume requires a destination file (use save or to=)")
+	}
+	if plan.Output != nil && len(plan.Output.Columns) > 0 && plan.Output.Format != "csv" && plan.Output.Format != "table" {
+		return fmt.Errorf("columns= requires as=csv or as=table")
+	}
+	if plan.Output != nil && plan.Output.Format == "envelope" {
+		switch {
+		case plan.Verb == types.VerbWatch:
+			return fmt.Errorf("as=envelope is not supported by watch")
+		case plan.Output.Destination != "":
+			return fmt.Errorf("as=envelope writes to stdout and cannot be combined with save or to=")
+		case plan.Output.Pick != "":
+			return fmt.Errorf("pick= cannot be combined with as=envelope; select from the envelope's body instead")
+		}
+	}
+	if plan.TLS != nil && plan.TLS.KeyFile != "" && plan.TLS.CertFile == "" {
+		return fmt.Errorf("key= requires cert=")
+	}
+	if plan.TLS != nil && len(plan.TLS.Pins) > 0 && !strings.HasPrefix(strings.ToLower(plan.URL), "https://") {
+		return fmt.Errorf("pin= requires an https:// URL")
+	}
+	if plan.Network != nil && plan.Network.IPVersion != "" && plan.Network.LocalAddress != "" {
+		isV4 := net.ParseIP(plan.Network.LocalAddress).To4() != nil
+		if isV4 != (plan.Network.IPVersion == "4") {
+			return fmt.Errorf("bind=%s is not an IPv%s address", plan.Network.LocalAddress, plan.Network.IPVersion)
+		}
+	}
+	if plan.Socket != "" && plan.Proxy != "" {
+		return fmt.Errorf("socket= cannot be combined with via= or proxy=")
+	}
+	if plan.HTTPVersion == "h2c" && strings.HasPrefix(strings.ToLower(plan.URL), "https://") {
+		return fmt.Errorf("http=h2c is cleartext HTTP/2; use http=2 for https:// URLs")
+	}
 	
 	// Validate upload verb: must have attach= or with=
 	// This check will be done after clauses are processed, so we check here
@@ -0,0 +1,233 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// table is tabular output: a header row and string cells, built from a JSON
+// array of objects or a CSV response.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// writeCSV writes body as RFC 4180 CSV. JSON becomes one row per array element
+// with nested objects flattened into dot-separated columns; a CSV response is
+// validated and re-encoded. columns, if given, selects and orders the columns.
+func writeCSV(w io.Writer, body []byte, contentType string, columns []string) error {
+	t, err := buildTable(body, contentType, columns)
+	if err != nil {
+		return err
+	}
+	if len(t.header) == 0 {
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	if err := writer.Write(t.header); err != nil {
+		return err
+	}
+	return writer.WriteAll(t.rows)
+}
+
+// buildTable converts a JSON or CSV response body into a table.
+func buildTable(body []byte, contentType string, columns []string) (*table, error) {
+	if !isCSVContentType(contentType) {
+		if data, err := decodeOrderedJSON(body); err == nil {
+			return tableFromJSON(data, columns)
+		}
+	}
+	t, err := tableFromCSV(body, columns)
+	if err != nil {
+		if isCSVContentType(contentType) {
+			return nil, fmt.Errorf("response is not valid CSV: %w", err)
+		}
+		return nil, fmt.Errorf("response is neither JSON nor CSV: %w", err)
+	}
+	return t, nil
+}
+
+// isCSVContentType reports whether a Content-Type header names a CSV media type.
+func isCSVContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/csv" || mediaType == "application/csv" || strings.HasSuffix(mediaType, "+csv")
+}
+
+// tableFromJSON flattens a JSON array (or a single object) into rows. The header is
+// the union of the flattened keys in the order they first appear; elements that
+// are not objects go in a "value" column.
+func tableFromJSON(data interface{}, columns []string) (*table, error) {
+	var records []interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		records = v
+	case *orderedObject:
+		records = []interface{}{v}
+	default:
+		return nil, fmt.Errorf("response must be a JSON array or object, not a single value")
+	}
+
+	var keys []string
+	known := make(map[string]bool)
+	flattened := make([]map[string]string, len(records))
+	for i, record := range records {
+		prefix := ""
+		if _, ok := record.(*orderedObject); !ok {
+			prefix = "value"
+		}
+		var order []string
+		cells := make(map[string]string)
+		flattenInto(prefix, record, cells, &order)
+		for _, key := range order {
+			if !known[key] {
+				known[key] = true
+				keys = append(keys, key)
+			}
+		}
+		flattened[i] = cells
+	}
+
+	t := &table{header: keys}
+	if len(columns) > 0 {
+		t.header = columns
+	}
+	found := make(map[string]bool)
+	for i, cells := range flattened {
+		row := make([]string, len(t.header))
+		for j, column := range t.header {
+			cell, ok := cells[column]
+			if !ok {
+				// A column may name a nested object or array kept whole
+				var value interface{}
+				if value, ok = lookupDotted(records[i], column); ok {
+					cell = cellText(value)
+				}
+			}
+			found[column] = found[column] || ok
+			row[j] = cell
+		}
+		t.rows = append(t.rows, row)
+	}
+	if len(records) > 0 {
+		warnMissingColumns(columns, found)
+	}
+	return t, nil
+}
+
+// flattenInto records value's cells under prefix, descending into non-empty objects
+// with dot-separated names. Cell names are appended to order as they are first set.
+func flattenInto(prefix string, value interface{}, cells map[string]string, order *[]string) {
+	if obj, ok := value.(*orderedObject); ok && (prefix == "" || len(obj.keys) > 0) {
+		for _, key := range obj.keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenInto(name, obj.values[key], cells, order)
+		}
+		return
+	}
+
+	if _, seen := cells[prefix]; !seen {
+		*order = append(*order, prefix)
+	}
+	cells[prefix] = cellText(value)
+}
+
+// lookupDotted walks a dot-separated key path through nested objects.
+func lookupDotted(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(*orderedObject)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj.Get(key); !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// cellText renders a JSON value as a cell: strings raw, null empty, and arrays or
+// objects as compact JSON.
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// tableFromCSV parses a CSV body, requiring every record to have the header's
+// number of fields, and applies the column selection by header name.
+func tableFromCSV(body []byte, columns []string) (*table, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &table{}, nil
+	}
+
+	t := &table{header: records[0], rows: records[1:]}
+	if len(columns) == 0 {
+		return t, nil
+	}
+
+	index := make(map[string]int)
+	for i, name := range t.header {
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	found := make(map[string]bool)
+	for _, column := range columns {
+		_, found[column] = index[column]
+	}
+	warnMissingColumns(columns, found)
+
+	selected := &table{header: columns}
+	for _, record := range t.rows {
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			if i, ok := index[column]; ok {
+				row[j] = record[i]
+			}
+		}
+		selected.rows = append(selected.rows, row)
+	}
+	return selected, nil
+}
+
+// warnMissingColumns notes selected columns that matched nothing, which are
+// usually typos; they are still output, empty.
+func warnMissingColumns(columns []string, found map[string]bool) {
+	for _, column := range columns {
+		if !found[column] {
+			fmt.Fprintf(os.Stderr, "Warning: column %q not found in response\n", column)
+		}
+	}
+}
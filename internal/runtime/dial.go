@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/adammpkins/req/internal/planner"
+)
+
+// dialFunc is the signature of http.Transport.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// networkDialer returns the transport's dial function: resolve= overrides, the
+// ip= family and the bind= local address are applied to every TCP connection,
+// including those to proxies and redirect targets.
+func networkDialer(network *planner.NetworkPlan) (dialFunc, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if network == nil {
+		return dialer.DialContext, nil
+	}
+
+	if network.LocalAddress != "" {
+		ip := net.ParseIP(network.LocalAddress)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid local address %q", network.LocalAddress)
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	family := ""
+	if network.IPVersion != "" {
+		family = "tcp" + network.IPVersion
+	}
+
+	return func(ctx context.Context, netw, addr string) (net.Conn, error) {
+		if target, ok := network.Resolve[strings.ToLower(addr)]; ok {
+			addr = target
+		}
+		if family != "" && strings.HasPrefix(netw, "tcp") {
+			netw = family
+		}
+		return dialer.DialContext(ctx, netw, addr)
+	}, nil
+}
@@ -0,0 +1,148 @@
+package runtime
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultAcceptEncoding lists the content codings decodeContent understands.
+const defaultAcceptEncoding = "gzip, br, zstd, deflate"
+
+// zstdMaxWindow is the largest zstd window accepted, per RFC 9659's limit for the
+// zstd content coding; it bounds decoder memory.
+const zstdMaxWindow = 8 << 20
+
+// contentEncodings returns the codings in a Content-Encoding header in the order
+// they must be removed (the reverse of the order they were applied), without identity.
+func contentEncodings(header http.Header) []string {
+	var encodings []string
+	for _, value := range header.Values("Content-Encoding") {
+		for _, enc := range strings.Split(value, ",") {
+			enc = strings.ToLower(strings.TrimSpace(enc))
+			if enc != "" && enc != "identity" {
+				encodings = append(encodings, enc)
+			}
+		}
+	}
+	for i, j := 0, len(encodings)-1; i < j; i, j = i+1, j-1 {
+		encodings[i], encodings[j] = encodings[j], encodings[i]
+	}
+	return encodings
+}
+
+// unsupportedEncoding returns the first coding in header that decodeContent cannot
+// remove, or "" if the body can be fully decoded.
+func unsupportedEncoding(header http.Header) string {
+	for _, enc := range contentEncodings(header) {
+		if !isSupportedEncoding(enc) {
+			return enc
+		}
+	}
+	return ""
+}
+
+func isSupportedEncoding(enc string) bool {
+	switch enc {
+	case "gzip", "x-gzip", "br", "zstd", "deflate":
+		return true
+	}
+	return false
+}
+
+// decodedBody is a response body with its content codings removed. Close releases
+// the decoders, such as zstd's buffers, but not the body they read from, which
+// stays with its owner.
+type decodedBody struct {
+	io.Reader
+	decoders []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var first error
+	for i := len(b.decoders) - 1; i >= 0; i-- {
+		if err := b.decoders[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// decodeContent removes the response's content codings from r. Decoding stops at
+// the first unsupported coding, with a warning, leaving the rest of the body
+// encoded. It reports whether any coding was removed.
+func decodeContent(r io.Reader, header http.Header) (*decodedBody, bool, error) {
+	body := &decodedBody{Reader: r}
+	encodings := contentEncodings(header)
+	if len(encodings) == 0 {
+		return body, false, nil
+	}
+
+	// Bodies of HEAD, 204 and 304 responses are empty despite the header
+	buffered := bufio.NewReader(r)
+	body.Reader = buffered
+	if _, err := buffered.Peek(1); err == io.EOF {
+		return body, false, nil
+	}
+
+	for _, enc := range encodings {
+		if !isSupportedEncoding(enc) {
+			fmt.Fprintf(os.Stderr, "Warning: unsupported Content-Encoding %q; body left encoded\n", enc)
+			break
+		}
+		next, err := newContentDecoder(enc, body.Reader)
+		if err != nil {
+			body.Close()
+			return nil, false, fmt.Errorf("failed to create %s reader: %w", enc, err)
+		}
+		body.Reader = next
+		body.decoders = append(body.decoders, next)
+	}
+	return body, len(body.decoders) > 0, nil
+}
+
+// newContentDecoder returns a reader that removes one supported content coding.
+func newContentDecoder(enc string, r io.Reader) (io.ReadCloser, error) {
+	switch enc {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "br":
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindow))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case "deflate":
+		return newDeflateReader(r)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", enc)
+}
+
+// newDeflateReader decodes "deflate" bodies, which RFC 9110 defines as zlib-wrapped
+// but some servers send as raw DEFLATE. A valid zlib header selects zlib.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == nil && isZlibHeader(header) {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// isZlibHeader reports whether b starts with a zlib header: compression method 8
+// (deflate), a window of at most 32KB, and a check value making the pair a multiple of 31.
+func isZlibHeader(b []byte) bool {
+	cmf, flg := b[0], b[1]
+	return cmf&0x0f == 8 && cmf>>4 <= 7 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
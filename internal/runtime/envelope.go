@@ -0,0 +1,168 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/types"
+)
+
+// resultEnvelope is the single JSON document written by as=envelope. It carries
+// what printMeta shows on stderr plus the body, so scripts need not scrape text.
+type resultEnvelope struct {
+	Status       int            `json:"status,omitempty"`
+	Protocol     string         `json:"protocol,omitempty"`
+	URL          string         `json:"url"`
+	Redirects    []redirectHop  `json:"redirects"`
+	Headers      http.Header    `json:"headers,omitempty"`
+	Timing       *timingReport  `json:"timing,omitempty"`
+	Attempts     int            `json:"attempts"`
+	Retries      int            `json:"retries"`
+	Expect       []expectResult `json:"expect,omitempty"`
+	Body         interface{}    `json:"body"`
+	BodyEncoding string         `json:"body_encoding,omitempty"` // "json", "text" or "base64"
+	Size         int            `json:"size"`
+	Error        string         `json:"error,omitempty"`
+	ExitCode     int            `json:"exit_code"`
+}
+
+// redirectHop is one redirect response followed on the way to the final URL.
+type redirectHop struct {
+	Status int    `json:"status"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// expectResult is the outcome of one expect= check.
+type expectResult struct {
+	Check   string `json:"check"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// isEnvelope reports whether the plan asks for the JSON result envelope.
+func isEnvelope(plan *planner.ExecutionPlan) bool {
+	return plan.Output != nil && plan.Output.Format == "envelope"
+}
+
+// writeEnvelope writes the result envelope for a completed response to stdout.
+// Every expect check runs so all results are reported, and the returned error
+// carries the same exit code as the plain output modes.
+func (e *Executor) writeEnvelope(resp *http.Response, body []byte, plan *planner.ExecutionPlan, attempts int) error {
+	envelope := e.newEnvelope(resp.Request.URL.String(), attempts)
+	envelope.Status = resp.StatusCode
+	envelope.Protocol = resp.Proto
+	envelope.Headers = resp.Header
+	envelope.Size = len(body)
+	envelope.Body, envelope.BodyEncoding = envelopeBody(body)
+
+	var result error
+	var failures []string
+	for _, check := range plan.Expect {
+		r := expectResult{Check: describeCheck(check), Passed: true}
+		if err := e.runExpectCheck(resp, body, check); err != nil {
+			r.Passed = false
+			r.Message = err.Error()
+			failures = append(failures, err.Error())
+		}
+		envelope.Expect = append(envelope.Expect, r)
+	}
+	switch {
+	case len(failures) > 0:
+		fmt.Fprintf(os.Stderr, "%s\n", strings.Join(failures, "\n"))
+		result = &ExecutionError{Code: 3, Message: "expectation failed"}
+	case len(plan.Expect) == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300):
+		result = &ExecutionError{Code: 4, Message: fmt.Sprintf("HTTP %d %s", resp.StatusCode, resp.Status)}
+	}
+
+	return writeEnvelopeResult(envelope, result)
+}
+
+// writeErrorEnvelope writes an envelope for a request that produced no usable
+// response, such as a connection failure, and returns err unchanged.
+func (e *Executor) writeErrorEnvelope(reqURL string, attempts int, err error) error {
+	envelope := e.newEnvelope(reqURL, attempts)
+	envelope.Error = err.Error()
+	return writeEnvelopeResult(envelope, err)
+}
+
+// newEnvelope starts an envelope with the redirect chain and timings recorded so far.
+func (e *Executor) newEnvelope(finalURL string, attempts int) *resultEnvelope {
+	envelope := &resultEnvelope{
+		URL:       finalURL,
+		Redirects: []redirectHop{},
+		Attempts:  attempts,
+	}
+	if attempts > 1 {
+		envelope.Retries = attempts - 1
+	}
+	if e.timing != nil {
+		report := e.timing.report()
+		envelope.Timing = &report
+		// Every hop before the final response was a redirect
+		for i := 0; i < len(report.Hops)-1; i++ {
+			hop := report.Hops[i]
+			envelope.Redirects = append(envelope.Redirects, redirectHop{Status: hop.Status, Method: hop.Method, URL: hop.URL})
+		}
+	}
+	return envelope
+}
+
+// writeEnvelopeResult sets the envelope's exit code from result, writes it to
+// stdout and returns result.
+func writeEnvelopeResult(envelope *resultEnvelope, result error) error {
+	var execErr *ExecutionError
+	if errors.As(result, &execErr) {
+		envelope.ExitCode = execErr.Code
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(envelope); err != nil {
+		return err
+	}
+	return result
+}
+
+// envelopeBody returns the body as parsed JSON when it is JSON (keeping key order,
+// as with as=json), as a string when it is text, and as base64 otherwise, with the
+// encoding used.
+func envelopeBody(body []byte) (interface{}, string) {
+	if len(body) == 0 {
+		return "", "text"
+	}
+	if data, err := decodeOrderedJSON(body); err == nil {
+		return data, "json"
+	}
+	if utf8.Valid(body) {
+		return string(body), "text"
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// describeCheck renders an expect check the way it is written in expect=.
+func describeCheck(check types.ExpectCheck) string {
+	switch check.Type {
+	case "status":
+		return "status:" + check.Value
+	case "header":
+		return "header:" + check.Name + "=" + check.Value
+	case "contains":
+		return "contains:" + check.Value
+	case "jsonpath":
+		if check.Op == "" || check.Op == "exists" {
+			return "jsonpath:" + check.Path
+		}
+		return "jsonpath:" + check.Path + check.Op + check.Value
+	case "matches":
+		return "matches:" + check.Regex
+	}
+	return check.Type
+}
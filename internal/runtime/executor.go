@@ -3,32 +3,38 @@ package runtime
 
 import (
 	"bytes"
-	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/andybalholm/brotli"
+	"github.com/adammpkins/req/internal/jsonpath"
 	"github.com/adammpkins/req/internal/planner"
-	"github.com/adammpkins/req/internal/types"
 	"github.com/adammpkins/req/internal/session"
+	"github.com/adammpkins/req/internal/types"
+	"github.com/mattn/go-isatty"
 )
 
 // Executor executes HTTP requests.
 type Executor struct {
-	client *http.Client
+	client      *http.Client
+	tokenClient *http.Client    // OAuth2 token refreshes, without pins, verbose, har= or timing
+	sizeLimit   *int64          // under=<size>, applied to decompressed response bodies
+	timing      *timingRecorder // per-hop phase timings, nil unless the timing flag is set
+	har         *harRecorder    // every round trip for har=, nil unless requested
 }
 
+// defaultTimeout bounds a request when under= doesn't give a duration.
+const defaultTimeout = 30 * time.Second
+
 // NewExecutor creates a new executor.
 func NewExecutor(plan *planner.ExecutionPlan) (*Executor, error) {
 	jar, err := cookiejar.New(nil)
@@ -36,40 +42,135 @@ func NewExecutor(plan *planner.ExecutionPlan) (*Executor, error) {
 		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{},
+	tlsConfig, err := buildTLSConfig(plan)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newTransport(plan, tlsConfig)
+	if err != nil {
+		return nil, err
 	}
 
-	// Configure TLS if insecure
 	if plan.Insecure {
-		transport.TLSClientConfig = getInsecureTLSConfig()
 		fmt.Fprintf(os.Stderr, "Warning: TLS verification disabled\n")
 	}
 
-	// Configure proxy if specified
-	if plan.Proxy != "" {
-		proxyURL, err := url.Parse(plan.Proxy)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %w", err)
-		}
-		transport.Proxy = http.ProxyURL(proxyURL)
+	// Token refreshes reach the token endpoint the way the first authenticate did,
+	// through the same CAs, client certificate, proxy and dialing, but pins and the
+	// server name belong to the target, and nothing records the exchange
+	tokenTLS := tlsConfig.Clone()
+	tokenTLS.VerifyConnection = nil
+	tokenTLS.ServerName = ""
+	tokenTransport, err := newTransport(plan, tokenTLS)
+	if err != nil {
+		return nil, err
 	}
+	tokenClient := &http.Client{Timeout: defaultTimeout, Transport: tokenTransport}
 
 	client := &http.Client{
-		Timeout:   30 * time.Second,
+		Timeout:   defaultTimeout,
 		Transport: transport,
 		Jar:       jar,
 	}
 
+	// Record every round trip, retries included, for har=. It sits next to the
+	// connection so it sees the request exactly as sent.
+	var har *harRecorder
+	if plan.HAR != nil {
+		har = newHARRecorder(plan.HAR)
+		client.Transport = &harTransport{next: client.Transport, rec: har}
+	}
+
+	// Trace each round trip, redirect hops included, for the timing flag and as=envelope
+	var timing *timingRecorder
+	if plan.Timing != "" || isEnvelope(plan) {
+		timing = &timingRecorder{format: plan.Timing}
+		client.Transport = &timingTransport{next: client.Transport, rec: timing}
+	}
+
+	// Dump every request and response, redirect hops included, for the verbose flag
+	if plan.Verbose {
+		client.Transport = &verboseTransport{next: client.Transport, showCredentials: plan.Unredacted}
+	}
+
 	if plan.Timeout != nil {
 		client.Timeout = *plan.Timeout
 	}
 
-	return &Executor{client: client}, nil
+	// Watch streams stay open indefinitely, so only bound the wait for response headers.
+	// The overall watch duration is enforced through the request context in Execute.
+	if plan.Verb == types.VerbWatch {
+		transport.ResponseHeaderTimeout = client.Timeout
+		client.Timeout = 0
+	}
+
+	return &Executor{client: client, tokenClient: tokenClient, sizeLimit: plan.SizeLimit, timing: timing, har: har}, nil
+}
+
+// newTransport returns a transport with the plan's TLS configuration, HTTP
+// version, dialing (resolve=, ip=, bind=, socket=) and proxy.
+func newTransport(plan *planner.ExecutionPlan, tlsConfig *tls.Config) (*http.Transport, error) {
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+
+	// Select the HTTP protocol version if specified
+	if plan.HTTPVersion != "" {
+		transport.Protocols = httpProtocols(plan.HTTPVersion)
+	}
+
+	// Route connections through resolve overrides, the IP family and local address
+	dial, err := networkDialer(plan.Network)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = dial
+
+	// Dial the Unix socket instead of the URL's host if specified
+	if plan.Socket != "" {
+		socketDial, err := socketDialer(plan.Socket, plan.URL, dial)
+		if err != nil {
+			return nil, err
+		}
+		transport.DialContext = socketDial
+	}
+
+	// Configure proxy if specified
+	if plan.Proxy != "" {
+		proxyURL, err := url.Parse(plan.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
+
+// httpProtocols returns the transport protocols for an http= version. Setting them
+// explicitly also re-enables HTTP/2, which a custom TLS config otherwise turns off.
+func httpProtocols(version string) *http.Protocols {
+	protocols := new(http.Protocols)
+	switch version {
+	case "1.1":
+		protocols.SetHTTP1(true)
+	case "2":
+		// HTTP/2 is negotiated over TLS with ALPN; cleartext URLs stay on HTTP/1.1
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case "h2c":
+		// Prior knowledge: speak HTTP/2 over cleartext without an upgrade
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	return protocols
 }
 
 // Execute executes an HTTP request based on the plan.
 func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
+	// Write the archive once everything, including reading the body, is done
+	if e.har != nil {
+		defer e.har.save()
+	}
+
 	// Build request URL with query parameters (preserving order)
 	reqURL, err := e.buildURL(plan)
 	if err != nil {
@@ -88,18 +189,47 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 		return &ExecutionError{Code: 5, Message: fmt.Sprintf("failed to create request: %v", err)}
 	}
 
+	// Multipart bodies are streamed rather than buffered
+	if streamed, ok := body.(*multipartBody); ok {
+		streamed.attach(req)
+	}
+
 	// Set headers
 	e.setHeaders(req, plan, contentType)
 
 	// Set cookies
 	e.setCookies(req, plan)
+	if err := e.applyCookieFile(req, plan); err != nil {
+		return &ExecutionError{Code: 5, Message: err.Error()}
+	}
 
 	// Auto-apply session if available and not explicitly set
 	e.autoApplySession(req, plan)
 
+	// Continue a partial download left by an earlier attempt
+	var resumeOffset int64
+	if plan.Resume {
+		resumeOffset = e.prepareResume(req, plan.Output.Destination)
+	}
+
 	// Add Accept-Encoding if not set by user
 	if req.Header.Get("Accept-Encoding") == "" {
-		req.Header.Set("Accept-Encoding", "gzip, br")
+		req.Header.Set("Accept-Encoding", defaultAcceptEncoding)
+	}
+
+	// Bound the whole watch, every poll included, by the under= deadline
+	if plan.Verb == types.VerbWatch {
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "text/event-stream, */*")
+		}
+		if plan.Timeout != nil {
+			ctx, cancel := context.WithTimeout(req.Context(), *plan.Timeout)
+			defer cancel()
+			req = req.WithContext(ctx)
+		}
+		if plan.Poll != nil {
+			return e.pollWatch(req, plan)
+		}
 	}
 
 	// Execute request with redirect handling
@@ -108,24 +238,48 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 	var redirectTrace []string
 	var bodyBytes []byte
 	var decompressed bool
-	var allSetCookies []string
+	var cookieResponses []*http.Response
+
+	var attempts int
 
 	if plan.Verb == types.VerbAuthenticate {
-		resp, redirectTrace, allSetCookies, err = e.executeWithRedirectsCapturingCookies(req, plan)
+		resp, attempts, err = e.executeWithRetry(req, plan, func(r *http.Request) (*http.Response, error) {
+			var err error
+			var resp *http.Response
+			e.timing.reset()
+			resp, redirectTrace, cookieResponses, err = e.executeWithRedirectsCapturingCookies(r, plan)
+			return resp, err
+		})
 		if err != nil {
-			return &ExecutionError{Code: 4, Message: fmt.Sprintf("request failed: %v", err)}
+			if isEnvelope(plan) {
+				return e.writeErrorEnvelope(reqURL, attempts, requestFailedError(err, attempts))
+			}
+			return requestFailedError(err, attempts)
 		}
 		defer resp.Body.Close()
 		// Also include Set-Cookie from final response
-		allSetCookies = append(allSetCookies, resp.Header.Values("Set-Cookie")...)
+		cookieResponses = append(cookieResponses, resp)
 	} else {
-		resp, redirectTrace, err = e.executeWithRedirects(req, plan)
+		resp, attempts, err = e.executeWithRetry(req, plan, func(r *http.Request) (*http.Response, error) {
+			var err error
+			var resp *http.Response
+			e.timing.reset()
+			resp, redirectTrace, err = e.executeWithRedirects(r, plan)
+			return resp, err
+		})
 		if err != nil {
-			return &ExecutionError{Code: 4, Message: fmt.Sprintf("request failed: %v", err)}
+			if isEnvelope(plan) {
+				return e.writeErrorEnvelope(reqURL, attempts, requestFailedError(err, attempts))
+			}
+			return requestFailedError(err, attempts)
 		}
 		defer resp.Body.Close()
 	}
 
+	if plan.Verbose {
+		fmt.Fprintf(os.Stderr, "Attempts: %d\n", attempts)
+	}
+
 	// Print redirect trace to stderr
 	if len(redirectTrace) > 0 {
 		for _, trace := range redirectTrace {
@@ -133,10 +287,24 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 		}
 	}
 
+	// Stream Server-Sent Events as they arrive instead of waiting for the body to end
+	if plan.Verb == types.VerbWatch && resp.StatusCode == http.StatusOK && isEventStream(resp) {
+		e.printMeta(resp, reqURL, -1, false)
+		return e.watchEvents(req, resp, plan)
+	}
+
+	// Stream downloads straight to disk instead of buffering the whole body
+	if plan.Output != nil && plan.Output.Destination != "" && plan.Verb != types.VerbAuthenticate {
+		return e.saveResponse(resp, reqURL, plan, resumeOffset)
+	}
+
 	// Read and decompress response body
 	bodyBytes, decompressed, err = e.readAndDecompress(resp)
 	if err != nil {
-		return &ExecutionError{Code: 4, Message: fmt.Sprintf("failed to read response: %v", err)}
+		if isEnvelope(plan) {
+			return e.writeErrorEnvelope(resp.Request.URL.String(), attempts, readError(err))
+		}
+		return readError(err)
 	}
 
 	if decompressed {
@@ -148,10 +316,15 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 
 	// Capture session for authenticate verb
 	if plan.Verb == types.VerbAuthenticate {
-		host, err := session.ExtractHost(plan.URL)
+		host, err := sessionHost(plan)
 		if err == nil {
-			updatedSession, err := session.UpdateSessionFromResponse(host, allSetCookies, bodyBytes)
+			updatedSession, err := session.UpdateSessionFromResponse(host, cookieResponses, bodyBytes)
 			if err == nil && updatedSession != nil {
+				// A client_credentials token is renewed by asking for a new one
+				if plan.Grant != nil {
+					clientID, clientSecret, _ := req.BasicAuth()
+					updatedSession.SetClientCredentials(clientID, clientSecret, plan.Grant.Scope)
+				}
 				if err := session.SaveSession(updatedSession); err == nil {
 					fmt.Fprintf(os.Stderr, "Session saved for %s\n", host)
 				}
@@ -159,6 +332,11 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 		}
 	}
 
+	// The envelope carries expect results and the body in one JSON document
+	if isEnvelope(plan) {
+		return e.writeEnvelope(resp, bodyBytes, plan, attempts)
+	}
+
 	// Run expect checks
 	if len(plan.Expect) > 0 {
 		if err := e.runExpectChecks(resp, bodyBytes, plan.Expect); err != nil {
@@ -174,21 +352,21 @@ func (e *Executor) Execute(plan *planner.ExecutionPlan) error {
 
 	// Handle output based on plan
 	if plan.Output != nil && plan.Output.Destination != "" {
-		// Save to file - uses io.Copy for efficient writing
-		// TODO: Optimize to stream directly from resp.Body when no expect checks
-		return e.saveToFile(bytes.NewReader(bodyBytes), plan.Output.Destination)
+		tmpPath, _, err := writeTempFile(bytes.NewReader(bodyBytes), plan.Output.Destination)
+		if err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, plan.Output.Destination)
 	}
 
-	// Handle watch verb with TTY detection
-	if plan.Verb == types.VerbWatch {
-		// TODO: Implement TTY detection
-		// TTY: timestamped lines
-		// Non-TTY: raw lines
-		return e.writeOutput(bodyBytes, plan.Output)
+	// Don't dump bytes that are still encoded onto a terminal
+	if enc := unsupportedEncoding(resp.Header); enc != "" && isatty.IsTerminal(os.Stdout.Fd()) {
+		fmt.Fprintf(os.Stderr, "Not printing %s-encoded body to the terminal; use to=<file> or a pipe to capture it\n", enc)
+		return nil
 	}
 
 	// Format and write output
-	return e.writeOutput(bodyBytes, plan.Output)
+	return e.writeOutput(bodyBytes, resp.Header.Get("Content-Type"), plan.Output)
 }
 
 // ExecutionError represents an execution error with exit code.
@@ -264,58 +442,6 @@ func (e *Executor) buildBody(plan *planner.ExecutionPlan) (io.Reader, string, er
 	return strings.NewReader(plan.Body.Content), contentType, nil
 }
 
-// buildMultipartBody builds a multipart/form-data body.
-func (e *Executor) buildMultipartBody(bodyPlan *planner.BodyPlan) (io.Reader, string, error) {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	boundary := bodyPlan.Boundary
-	if boundary == "" {
-		boundary = writer.Boundary()
-	} else {
-		writer.SetBoundary(boundary)
-	}
-
-	for _, part := range bodyPlan.AttachParts {
-		var partWriter io.Writer
-		var err error
-
-		// Create form field
-		if part.Filename != "" {
-			partWriter, err = writer.CreateFormFile(part.Name, part.Filename)
-		} else {
-			partWriter, err = writer.CreateFormField(part.Name)
-		}
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to create form field: %w", err)
-		}
-
-		// Write part content
-		if part.FilePath != "" {
-			// Read file
-			data, err := os.ReadFile(part.FilePath)
-			if err != nil {
-				return nil, "", fmt.Errorf("failed to read file %s: %w", part.FilePath, err)
-			}
-			if _, err := partWriter.Write(data); err != nil {
-				return nil, "", fmt.Errorf("failed to write file data: %w", err)
-			}
-		} else {
-			// Write value
-			if _, err := partWriter.Write([]byte(part.Value)); err != nil {
-				return nil, "", fmt.Errorf("failed to write value: %w", err)
-			}
-		}
-	}
-
-	if err := writer.Close(); err != nil {
-		return nil, "", fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
-	return &buf, contentType, nil
-}
-
 // setHeaders sets request headers.
 func (e *Executor) setHeaders(req *http.Request, plan *planner.ExecutionPlan, contentType string) {
 	// Set user headers first
@@ -347,11 +473,28 @@ func (e *Executor) setCookies(req *http.Request, plan *planner.ExecutionPlan) {
 	}
 }
 
+// applyCookieFile adds the cookies from a cookies= file that match the request,
+// using the same domain, path, secure and expiry rules as stored sessions.
+func (e *Executor) applyCookieFile(req *http.Request, plan *planner.ExecutionPlan) error {
+	if plan.CookieFile == "" {
+		return nil
+	}
+	cookies, err := session.ReadCookieFile(plan.CookieFile)
+	if err != nil {
+		return err
+	}
+	jar := &session.Session{Cookies: cookies}
+	for _, cookie := range jar.CookiesFor(req.URL, time.Now()) {
+		req.AddCookie(cookie)
+	}
+	return nil
+}
+
 // autoApplySession automatically applies a stored session if available.
 func (e *Executor) autoApplySession(req *http.Request, plan *planner.ExecutionPlan) {
-	// Don't auto-apply if Authorization or Cookie headers are explicitly set
+	// Don't auto-apply if Authorization or Cookie headers are explicitly set, or come from cookies=
 	hasAuth := req.Header.Get("Authorization") != ""
-	hasCookie := false
+	hasCookie := plan.CookieFile != ""
 	for name := range plan.Cookies {
 		if name != "" {
 			hasCookie = true
@@ -362,8 +505,8 @@ func (e *Executor) autoApplySession(req *http.Request, plan *planner.ExecutionPl
 		return
 	}
 
-	// Extract host from URL
-	host, err := session.ExtractHost(plan.URL)
+	// Sessions are keyed by host, or by socket for Unix socket requests
+	host, err := sessionHost(plan)
 	if err != nil {
 		return
 	}
@@ -374,17 +517,19 @@ func (e *Executor) autoApplySession(req *http.Request, plan *planner.ExecutionPl
 		return
 	}
 
+	// Renew an expired or nearly expired OAuth2 token before sending
+	if sess.TokenExpiring(time.Now()) {
+		e.refreshToken(sess, host)
+	}
+
 	// Apply authorization if available
 	if sess.Authorization != "" {
 		req.Header.Set("Authorization", sess.Authorization)
 	}
 
-	// Apply cookies
-	for name, value := range sess.Cookies {
-		req.AddCookie(&http.Cookie{
-			Name:  name,
-			Value: value,
-		})
+	// Apply the cookies whose domain, path, scheme and expiry match this request
+	for _, cookie := range sess.CookiesFor(req.URL, time.Now()) {
+		req.AddCookie(cookie)
 	}
 
 	fmt.Fprintf(os.Stderr, "Using session for %s\n", host)
@@ -432,14 +577,14 @@ func (e *Executor) executeWithRedirects(req *http.Request, plan *planner.Executi
 
 		// For smart follow with write verbs, only follow 307/308
 		if plan.Follow == "smart" && isWriteVerb {
-			statusCode := via[len(via)-1].Response.StatusCode
+			statusCode := req.Response.StatusCode
 			if statusCode != 307 && statusCode != 308 {
 				return fmt.Errorf("write verb: not following %d redirect (use 307/308)", statusCode)
 			}
 		}
 
 		redirects++
-		statusCode := via[len(via)-1].Response.StatusCode
+		statusCode := req.Response.StatusCode
 		redirectTrace = append(redirectTrace, fmt.Sprintf("→ %d %s %s", statusCode, req.Method, req.URL.String()))
 		return nil
 	}
@@ -449,125 +594,71 @@ func (e *Executor) executeWithRedirects(req *http.Request, plan *planner.Executi
 }
 
 // executeWithRedirectsCapturingCookies executes the request with redirect handling,
-// capturing Set-Cookie headers from all redirect responses.
+// returning each redirect response so their Set-Cookie headers can be stored
+// against the URL that set them.
 // This is needed for authenticate verb to capture cookies from redirect responses.
-func (e *Executor) executeWithRedirectsCapturingCookies(req *http.Request, plan *planner.ExecutionPlan) (*http.Response, []string, []string, error) {
-	maxRedirects := 5
+// The client follows the redirects itself, so credentials are dropped on a move to
+// another host and 307/308 replay the body as usual.
+func (e *Executor) executeWithRedirectsCapturingCookies(req *http.Request, plan *planner.ExecutionPlan) (*http.Response, []string, []*http.Response, error) {
+	maxRedirects := 10
 	var redirectTrace []string
-	var allSetCookies []string
+	var redirectResponses []*http.Response
 
-	// Use the client's CookieJar to automatically handle cookies during redirects
-	// Then extract cookies from the jar after redirects complete
 	client := *e.client
-	client.CheckRedirect = nil // Disable automatic redirect following
-
-	originalURL := req.URL
-	for i := 0; i < maxRedirects; i++ {
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, redirectTrace, allSetCookies, err
-		}
-
-		// Capture Set-Cookie headers from this response
-		setCookies := resp.Header.Values("Set-Cookie")
-		allSetCookies = append(allSetCookies, setCookies...)
-
-		// Check if this is a redirect
-		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
-			location := resp.Header.Get("Location")
-			if location == "" {
-				resp.Body.Close()
-				return resp, redirectTrace, allSetCookies, nil
-			}
-
-			// Parse the location URL
-			redirectURL, err := url.Parse(location)
-			if err != nil {
-				resp.Body.Close()
-				return resp, redirectTrace, allSetCookies, fmt.Errorf("invalid redirect URL: %w", err)
-			}
-
-			// Make location absolute if needed
-			if !redirectURL.IsAbs() {
-				baseURL, _ := url.Parse(req.URL.String())
-				redirectURL = baseURL.ResolveReference(redirectURL)
-			}
-
-			// Create new request for redirect
-			redirectTrace = append(redirectTrace, fmt.Sprintf("→ %d %s %s", resp.StatusCode, req.Method, redirectURL.String()))
-			resp.Body.Close()
-
-			// Create new request for redirect (preserve method for 307/308)
-			method := req.Method
-			if resp.StatusCode == 301 || resp.StatusCode == 302 || resp.StatusCode == 303 {
-				// Change to GET for these redirects
-				method = "GET"
-			}
-
-			newReq, err := http.NewRequest(method, redirectURL.String(), nil)
-			if err != nil {
-				return nil, redirectTrace, allSetCookies, fmt.Errorf("failed to create redirect request: %w", err)
-			}
-
-			// Copy headers from original request
-			for k, v := range req.Header {
-				newReq.Header[k] = v
-			}
-			req = newReq
-			continue
-		}
-
-		// Not a redirect, return the response
-		// Extract cookies from CookieJar for the original host
-		if client.Jar != nil {
-			hostURL, err := url.Parse(originalURL.Scheme + "://" + originalURL.Host)
-			if err == nil {
-				jarCookies := client.Jar.Cookies(hostURL)
-				// Convert jar cookies to Set-Cookie format strings
-				for _, cookie := range jarCookies {
-					allSetCookies = append(allSetCookies, fmt.Sprintf("%s=%s", cookie.Name, cookie.Value))
-				}
-			}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
 		}
-		return resp, redirectTrace, allSetCookies, nil
+		// The hop's body is closed once this returns; its headers stay readable
+		redirectResponses = append(redirectResponses, req.Response)
+		redirectTrace = append(redirectTrace, fmt.Sprintf("→ %d %s %s", req.Response.StatusCode, req.Method, req.URL.String()))
+		return nil
 	}
 
-	return nil, redirectTrace, allSetCookies, fmt.Errorf("stopped after %d redirects", maxRedirects)
+	resp, err := client.Do(req)
+	return resp, redirectTrace, redirectResponses, err
 }
 
 // readAndDecompress reads and decompresses the response body.
 func (e *Executor) readAndDecompress(resp *http.Response) ([]byte, bool, error) {
-	var reader io.Reader = resp.Body
-	decompressed := false
-
-	// Check if compressed - handle case-insensitive and multiple encodings
-	encoding := resp.Header.Get("Content-Encoding")
-	if encoding != "" {
-		// Split by comma and check each encoding (case-insensitive)
-		// Process encodings in reverse order (last encoding applied first)
-		encodings := strings.Split(encoding, ",")
-		for i := len(encodings) - 1; i >= 0; i-- {
-			enc := strings.TrimSpace(strings.ToLower(encodings[i]))
-			if enc == "gzip" {
-				gzipReader, err := gzip.NewReader(reader)
-				if err != nil {
-					return nil, false, fmt.Errorf("failed to create gzip reader: %w", err)
-				}
-				defer gzipReader.Close()
-				reader = gzipReader
-				decompressed = true
-			} else if enc == "br" {
-				// brotli.Reader implements io.Reader
-				reader = brotli.NewReader(reader)
-				decompressed = true
-			}
-		}
+	reader, decompressed, err := e.decompressReader(resp)
+	if err != nil {
+		return nil, false, err
 	}
+	defer reader.Close()
 
 	data, err := io.ReadAll(reader)
 	return data, decompressed, err
 }
 
+// decompressReader wraps the response body with decoders for its Content-Encoding.
+// When under=<size> is set, the limit applies to the decompressed stream so a
+// small compressed body cannot expand past it. Closing the reader releases the
+// decoders; the response body is still closed separately.
+func (e *Executor) decompressReader(resp *http.Response) (io.ReadCloser, bool, error) {
+	return e.decompressReaderAt(resp, 0)
+}
+
+// decompressReaderAt is decompressReader for a body that continues written bytes
+// already saved, such as a resumed download. The bytes already written count
+// against under=<size>, so the limit applies to the whole file.
+func (e *Executor) decompressReaderAt(resp *http.Response, written int64) (io.ReadCloser, bool, error) {
+	if err := e.checkContentLength(resp, written); err != nil {
+		return nil, false, err
+	}
+
+	body, decompressed, err := decodeContent(resp.Body, resp.Header)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if e.sizeLimit != nil {
+		body.Reader = newLimitedReader(body.Reader, *e.sizeLimit, written)
+	}
+
+	return body, decompressed, nil
+}
+
 // runExpectChecks runs expectation checks on the response.
 func (e *Executor) runExpectChecks(resp *http.Response, body []byte, checks []types.ExpectCheck) error {
 	for _, check := range checks {
@@ -600,14 +691,7 @@ func (e *Executor) runExpectCheck(resp *http.Response, body []byte, check types.
 		}
 
 	case "jsonpath":
-		// Simple JSON path extraction (basic implementation)
-		var data interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			return fmt.Errorf("failed to parse JSON: %w", err)
-		}
-		// TODO: Implement proper JSONPath evaluation
-		// For now, just check if JSON is valid
-		_ = data
+		return checkJSONPath(body, check)
 
 	case "matches":
 		matched, err := regexp.MatchString(check.Regex, string(body))
@@ -625,29 +709,82 @@ func (e *Executor) runExpectCheck(resp *http.Response, body []byte, check types.
 	return nil
 }
 
+// lookupJSONPath evaluates a JSONPath expression against decoded JSON. Singular paths
+// ($.a.b, $.items[0].id) yield the selected value; other paths yield the array of matches.
+func lookupJSONPath(data interface{}, expr string) (interface{}, error) {
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	matches := path.Evaluate(data)
+	if path.Singular() {
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no match")
+		}
+		return matches[0], nil
+	}
+	if matches == nil {
+		matches = []interface{}{}
+	}
+	return matches, nil
+}
+
+// formatJSONValue renders a decoded JSON value as text: strings raw, everything else as JSON.
+func formatJSONValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
 // printMeta prints metadata to stderr.
+// A negative bodySize means the size is not known (e.g. a stream) and is omitted.
 func (e *Executor) printMeta(resp *http.Response, url string, bodySize int, decompressed bool) {
 	fmt.Fprintf(os.Stderr, "HTTP %d\n", resp.StatusCode)
 	fmt.Fprintf(os.Stderr, "URL: %s\n", url)
-	fmt.Fprintf(os.Stderr, "Size: %d bytes\n", bodySize)
+	fmt.Fprintf(os.Stderr, "Protocol: %s\n", resp.Proto)
+	if bodySize >= 0 {
+		fmt.Fprintf(os.Stderr, "Size: %d bytes\n", bodySize)
+	}
 	if ct := resp.Header.Get("Content-Type"); ct != "" {
 		fmt.Fprintf(os.Stderr, "Content-Type: %s\n", ct)
 	}
+	e.timing.print(os.Stderr)
 }
 
-// writeOutput formats and writes output to stdout.
-func (e *Executor) writeOutput(body []byte, output *planner.OutputPlan) error {
+// writeOutput formats and writes output to stdout. contentType is the response's
+// Content-Type, which tells formats such as csv how to read the body.
+func (e *Executor) writeOutput(body []byte, contentType string, output *planner.OutputPlan) error {
 	if output == nil {
 		// Default: raw output
 		_, err := os.Stdout.Write(body)
 		return err
 	}
 
+	// Narrow the response with pick= before formatting
+	if output.Pick != "" {
+		picked, scalar, err := pickJSON(body, output.Pick)
+		if err != nil {
+			return &ExecutionError{Code: 9, Message: fmt.Sprintf("pick=%s: %v", output.Pick, err)}
+		}
+		if scalar {
+			// Scalars print raw so they can be captured straight into shell variables
+			_, err := os.Stdout.Write(picked)
+			return err
+		}
+		body = picked
+		contentType = "application/json"
+	}
+
 	switch output.Format {
 	case "json":
-		// Pretty print JSON
-		var data interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
+		// Pretty print JSON, keeping the response's key order and number formatting
+		data, err := decodeOrderedJSON(body)
+		if err != nil {
 			// Not JSON, output as-is
 			_, err := os.Stdout.Write(body)
 			return err
@@ -656,6 +793,24 @@ func (e *Executor) writeOutput(body []byte, output *planner.OutputPlan) error {
 		encoder.SetIndent("", "  ")
 		return encoder.Encode(data)
 
+	case "yaml":
+		data, err := decodeOrderedJSON(body)
+		if err != nil {
+			// Not JSON, output as-is
+			_, err := os.Stdout.Write(body)
+			return err
+		}
+		return writeYAML(os.Stdout, data)
+
+	case "ndjson":
+		values, err := decodeJSONStream(body)
+		if err != nil {
+			// Not JSON, output as-is
+			_, err := os.Stdout.Write(body)
+			return err
+		}
+		return writeNDJSON(os.Stdout, values)
+
 	case "text":
 		// Output as text
 		_, err := os.Stdout.Write(body)
@@ -667,9 +822,17 @@ func (e *Executor) writeOutput(body []byte, output *planner.OutputPlan) error {
 		return err
 
 	case "csv":
-		// CSV output (basic - would need proper CSV parsing)
-		_, err := os.Stdout.Write(body)
-		return err
+		if err := writeCSV(os.Stdout, body, contentType, output.Columns); err != nil {
+			return &ExecutionError{Code: 9, Message: fmt.Sprintf("as=csv: %v", err)}
+		}
+		return nil
+
+	case "table":
+		tty := isatty.IsTerminal(os.Stdout.Fd())
+		if err := writeTable(os.Stdout, body, contentType, output.Columns, tty, terminalWidth(tty)); err != nil {
+			return &ExecutionError{Code: 9, Message: fmt.Sprintf("as=table: %v", err)}
+		}
+		return nil
 
 	default:
 		// Default: raw
@@ -678,39 +841,6 @@ func (e *Executor) writeOutput(body []byte, output *planner.OutputPlan) error {
 	}
 }
 
-// saveToFile saves the response body to a file.
-func (e *Executor) saveToFile(body io.Reader, destination string) error {
-	// Create directory if needed
-	dir := filepath.Dir(destination)
-	if dir != "." && dir != "" {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-	}
-
-	// Create file
-	file, err := os.Create(destination)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	// Copy response body to file
-	_, err = io.Copy(file, body)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
-}
-
-// getInsecureTLSConfig returns an insecure TLS config.
-func getInsecureTLSConfig() *tls.Config {
-	return &tls.Config{
-		InsecureSkipVerify: true,
-	}
-}
-
 // ExecuteWithResponse executes an HTTP request and returns the response body as a string.
 // This is useful for TUI mode where we need to capture and format the response.
 func (e *Executor) ExecuteWithResponse(plan *planner.ExecutionPlan) (string, error) {
@@ -737,6 +867,9 @@ func (e *Executor) ExecuteWithResponse(plan *planner.ExecutionPlan) (string, err
 
 	// Set cookies
 	e.setCookies(req, plan)
+	if err := e.applyCookieFile(req, plan); err != nil {
+		return "", err
+	}
 
 	// Execute request
 	resp, _, err := e.executeWithRedirects(req, plan)
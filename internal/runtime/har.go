@@ -0,0 +1,462 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/adammpkins/req/internal/planner"
+)
+
+// harBodyLimit caps how much of each request and response body a HAR file records.
+const harBodyLimit = 1 << 20
+
+// Version is the req version named as the creator of HAR files. The command sets
+// it from the version stamped in at build time.
+var Version = "dev"
+
+// harRecorder records every round trip of a command, including redirect hops and
+// retried attempts, for writing as an HTTP Archive (HAR 1.2).
+type harRecorder struct {
+	path   string
+	redact bool
+	timing *timingRecorder // never reset, so earlier attempts keep their timings
+
+	mu        sync.Mutex
+	exchanges []*harExchange
+}
+
+// harExchange is one recorded round trip.
+type harExchange struct {
+	hop      *hopTiming
+	started  time.Time
+	req      *http.Request
+	reqBody  []byte
+	reqNote  string // why the request body was not recorded, or that it was truncated
+	resp     *http.Response
+	respBody *harBody
+	err      error
+}
+
+// harTransport records each round trip through next into rec.
+type harTransport struct {
+	next http.RoundTripper
+	rec  *harRecorder
+}
+
+func newHARRecorder(plan *planner.HARPlan) *harRecorder {
+	return &harRecorder{path: plan.Path, redact: plan.Redact, timing: &timingRecorder{}}
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, hop := t.rec.timing.trace(req)
+	exchange := &harExchange{hop: hop, started: time.Now(), req: req}
+	if req.Body != nil && req.Body != http.NoBody {
+		data, truncated, note := replayBody(req, harBodyLimit)
+		exchange.reqBody, exchange.reqNote = data, note
+		if truncated {
+			exchange.reqNote = fmt.Sprintf("truncated to %d of %s", harBodyLimit, bodySize(req))
+		}
+	}
+	t.rec.mu.Lock()
+	t.rec.exchanges = append(t.rec.exchanges, exchange)
+	t.rec.mu.Unlock()
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.rec.timing.finish(hop, nil)
+		exchange.err = err
+		return nil, err
+	}
+	t.rec.timing.finish(hop, resp)
+	exchange.resp = resp
+	exchange.respBody = &harBody{ReadCloser: resp.Body}
+	resp.Body = &timedBody{ReadCloser: exchange.respBody, done: func() { t.rec.timing.mark(&hop.end, false) }}
+	return resp, nil
+}
+
+// harBody keeps a copy of up to harBodyLimit bytes of a response body as it is read.
+type harBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	size      int64
+	truncated bool
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if room := harBodyLimit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if n > 0 && b.size > harBodyLimit {
+		b.truncated = true
+	}
+	return n, err
+}
+
+// HAR 1.2 document types (http://www.softwareishard.com/blog/har-12-spec/).
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// harTimings are in milliseconds; -1 marks a phase that did not happen. Per the
+// spec, connect includes the TLS handshake, which is also reported as ssl.
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// save writes the HAR file, reporting the outcome on stderr. It runs once the
+// command's exchange is over, so a failure to write never changes the exit code.
+func (r *harRecorder) save() {
+	doc := r.document()
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err == nil {
+		err = writePrivateFile(r.path, append(data, '\n'))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write HAR file %s: %v\n", r.path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "HAR written to %s (%d entries)\n", r.path, len(doc.Log.Entries))
+}
+
+// writePrivateFile replaces path with data, readable only by the owner. The
+// archive holds request headers and bodies, so it is kept private like sessions;
+// writing a temp file and renaming it also tightens an existing file's mode,
+// which os.WriteFile leaves alone.
+func writePrivateFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// document builds the HAR document from the recorded exchanges.
+func (r *harRecorder) document() harDocument {
+	r.mu.Lock()
+	exchanges := append([]*harExchange(nil), r.exchanges...)
+	r.mu.Unlock()
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "req", Version: Version},
+		Entries: []harEntry{},
+	}}
+
+	r.timing.mu.Lock()
+	defer r.timing.mu.Unlock()
+	for _, exchange := range exchanges {
+		doc.Log.Entries = append(doc.Log.Entries, r.entry(exchange))
+	}
+	return doc
+}
+
+// entry converts one exchange. The caller holds r.timing.mu.
+func (r *harRecorder) entry(x *harExchange) harEntry {
+	entry := harEntry{
+		StartedDateTime: x.started.Format(time.RFC3339Nano),
+		Request:         r.request(x),
+		Timings:         harTimingsFor(x.hop),
+	}
+	if host, _, err := net.SplitHostPort(x.hop.remoteAddr); err == nil {
+		entry.ServerIPAddress = host
+	}
+	for _, t := range []float64{entry.Timings.Blocked, entry.Timings.DNS, entry.Timings.Connect, entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+		if t > 0 {
+			entry.Time += t
+		}
+	}
+
+	if x.resp == nil {
+		// No response: HAR still requires one, so record status 0 with the error
+		entry.Response = harResponse{HTTPVersion: "", Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+		if x.err != nil {
+			entry.Error = x.err.Error()
+		}
+		return entry
+	}
+	entry.Request.HTTPVersion = x.resp.Proto
+	entry.Response = r.response(x)
+	return entry
+}
+
+// request converts the request side of an exchange.
+func (r *harRecorder) request(x *harExchange) harRequest {
+	req := x.req
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	request := harRequest{
+		Method:      req.Method,
+		URL:         r.url(req.URL),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harCookie{},
+		Headers:     append([]harNameValue{{Name: "Host", Value: host}}, r.headers(req.Header)...),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	for _, cookie := range req.Cookies() {
+		request.Cookies = append(request.Cookies, harCookie{Name: cookie.Name, Value: r.secret(cookie.Value)})
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			if credentialFields[name] {
+				value = r.secret(value)
+			}
+			request.QueryString = append(request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body := x.reqBody
+		if r.redact {
+			body = redactBody(req.Header.Get("Content-Type"), body)
+		}
+		request.BodySize = req.ContentLength
+		request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(body),
+			Comment:  x.reqNote,
+		}
+		if !utf8.Valid(x.reqBody) {
+			request.PostData.Text = ""
+			request.PostData.Comment = "binary body not recorded"
+		}
+	}
+	return request
+}
+
+// response converts the response side of an exchange, decoding the recorded body.
+func (r *harRecorder) response(x *harExchange) harResponse {
+	resp := x.resp
+	response := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harCookie{},
+		Headers:     r.headers(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    x.respBody.size,
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+	}
+	for _, cookie := range resp.Cookies() {
+		c := harCookie{
+			Name:     cookie.Name,
+			Value:    r.secret(cookie.Value),
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+		response.Cookies = append(response.Cookies, c)
+	}
+
+	raw := x.respBody.buf.Bytes()
+	content := raw
+	if reader, decoded, err := harDecode(raw, resp.Header); err == nil && decoded {
+		if data, err := io.ReadAll(reader); err == nil {
+			content = data
+		} else {
+			response.Content.Comment = "could not decode " + resp.Header.Get("Content-Encoding") + " body; recorded as sent"
+		}
+		reader.Close()
+	}
+	response.Content.Size = int64(len(content))
+	if utf8.Valid(content) && bytes.IndexByte(content, 0) < 0 {
+		response.Content.Text = string(content)
+	} else {
+		response.Content.Text = base64.StdEncoding.EncodeToString(content)
+		response.Content.Encoding = "base64"
+	}
+	if x.respBody.truncated {
+		response.Content.Comment = fmt.Sprintf("truncated to %d of %d bytes", harBodyLimit, x.respBody.size)
+	}
+	return response
+}
+
+// harDecode removes the response's content codings from raw. Bodies with an
+// unsupported coding are left as sent; the output already warned about them.
+func harDecode(raw []byte, header http.Header) (io.ReadCloser, bool, error) {
+	for _, enc := range contentEncodings(header) {
+		if !isSupportedEncoding(enc) {
+			return nil, false, nil
+		}
+	}
+	body, decoded, err := decodeContent(bytes.NewReader(raw), header)
+	if err != nil {
+		return nil, false, err
+	}
+	return body, decoded, nil
+}
+
+// headers converts headers sorted by name, redacting credentials when requested.
+func (r *harRecorder) headers(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			if r.redact {
+				value = redactHeader(name, value)
+			}
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// url returns u as a string, hiding credential query parameters such as
+// access_token when the recorder redacts credentials.
+func (r *harRecorder) url(u *url.URL) string {
+	if !r.redact || u.RawQuery == "" {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = redactForm(u.RawQuery)
+	return redacted.String()
+}
+
+// secret returns value, or "***" when the recorder redacts credentials.
+func (r *harRecorder) secret(value string) string {
+	if r.redact {
+		return "***"
+	}
+	return value
+}
+
+// harTimingsFor converts a hop's trace into HAR timings.
+func harTimingsFor(hop *hopTiming) harTimings {
+	timings := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if !hop.dnsStart.IsZero() {
+		timings.DNS = millis(hop.dnsStart, hop.dnsDone)
+	}
+	if !hop.connectStart.IsZero() {
+		timings.Connect = millis(hop.connectStart, hop.connectDone)
+	}
+	if !hop.tlsStart.IsZero() {
+		timings.SSL = millis(hop.tlsStart, hop.tlsDone)
+		timings.Connect = max(timings.Connect, 0) + timings.SSL
+	}
+	if !hop.gotConn.IsZero() {
+		// Whatever part of getting a connection was not DNS, connecting or TLS was spent waiting
+		timings.Blocked = max(millis(hop.start, hop.gotConn)-max(timings.DNS, 0)-max(timings.Connect, 0), 0)
+	}
+	timings.Send = millis(hop.gotConn, hop.wroteRequest)
+	timings.Wait = millis(hop.wroteRequest, hop.firstByte)
+	timings.Receive = millis(hop.firstByte, hop.end)
+	return timings
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adammpkins/req/internal/jsonpath"
+	"github.com/adammpkins/req/internal/types"
+)
+
+// maxShownValue caps how much of an actual value a failure message includes.
+const maxShownValue = 120
+
+// checkJSONPath runs a jsonpath expect check. The path must match; a singular path
+// compares its value, and any other path requires every match to satisfy the check.
+func checkJSONPath(body []byte, check types.ExpectCheck) error {
+	data, err := decodeOrderedJSON(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	path, err := jsonpath.Parse(check.Path)
+	if err != nil {
+		return fmt.Errorf("jsonpath %s: %w", check.Path, err)
+	}
+
+	matches := path.Evaluate(data)
+	if len(matches) == 0 {
+		if check.Op == "" || check.Op == "exists" {
+			return fmt.Errorf("expected %s to exist, but it matched nothing", check.Path)
+		}
+		return fmt.Errorf("expected %s %s %s, but %s matched nothing", check.Path, check.Op, check.Value, check.Path)
+	}
+	if check.Op == "" || check.Op == "exists" {
+		return nil
+	}
+
+	for _, actual := range matches {
+		if !compareJSONValue(actual, check) {
+			return fmt.Errorf("expected %s %s %s, got %s", check.Path, check.Op, check.Value, showJSONValue(actual))
+		}
+	}
+	return nil
+}
+
+// compareJSONValue compares an actual value against the check's typed literal.
+// A string value is also equal to the text of a number or boolean literal, so
+// $.version==2 matches both 2 and "2".
+func compareJSONValue(actual interface{}, check types.ExpectCheck) bool {
+	if s, ok := actual.(string); ok && (check.Op == "==" || check.Op == "!=") {
+		switch check.Expected.(type) {
+		case float64, bool:
+			if s == check.Value {
+				return check.Op == "=="
+			}
+		}
+	}
+	return jsonpath.Compare(actual, true, check.Op, check.Expected, true)
+}
+
+// showJSONValue renders a value for a failure message as JSON, so strings are quoted
+// and "42" is distinguishable from 42.
+func showJSONValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(data) > maxShownValue {
+		return string(data[:maxShownValue]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,85 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sizeLimitError reports a response body larger than under=<size> allows.
+type sizeLimitError struct {
+	limit int64
+}
+
+func (e *sizeLimitError) Error() string {
+	return fmt.Sprintf("response exceeds size limit of %s", formatBytes(e.limit))
+}
+
+// limitedReader returns a sizeLimitError once more than limit bytes have been read.
+// Unlike io.LimitReader it fails loudly instead of silently truncating the body.
+type limitedReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+// newLimitedReader limits r to what is left of limit after used bytes.
+func newLimitedReader(r io.Reader, limit, used int64) *limitedReader {
+	return &limitedReader{r: r, limit: limit, remaining: max(limit-used, 0)}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// Ask for one byte past the limit so exceeding it is detected without reading further
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		return n, &sizeLimitError{limit: l.limit}
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// checkContentLength rejects a response whose declared size, added to the used
+// bytes already saved, exceeds the limit before any of the body is read.
+func (e *Executor) checkContentLength(resp *http.Response, used int64) error {
+	if e.sizeLimit == nil {
+		return nil
+	}
+	if used > *e.sizeLimit {
+		return &sizeLimitError{limit: *e.sizeLimit}
+	}
+	// An encoded body's length says nothing about its decoded size, which is what
+	// the limit covers; the limited reader catches it while decoding instead
+	if !hasBody(resp) || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	if resp.ContentLength > *e.sizeLimit-used {
+		return &sizeLimitError{limit: *e.sizeLimit}
+	}
+	return nil
+}
+
+// hasBody reports whether the response carries a body. HEAD responses and 1xx,
+// 204 and 304 statuses declare a Content-Length without sending one.
+func hasBody(resp *http.Response) bool {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+	code := resp.StatusCode
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// readError converts a failure while reading the response body into an ExecutionError,
+// using exit code 7 when the size limit was exceeded.
+func readError(err error) error {
+	var limitErr *sizeLimitError
+	if errors.As(err, &limitErr) {
+		return &ExecutionError{Code: 7, Message: limitErr.Error()}
+	}
+	return &ExecutionError{Code: 4, Message: fmt.Sprintf("failed to read response: %v", err)}
+}
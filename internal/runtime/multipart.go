@@ -0,0 +1,254 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/types"
+	"github.com/mattn/go-isatty"
+)
+
+// multipartBody streams a multipart/form-data body through an io.Pipe, reading
+// attached files as the request is sent instead of buffering them in memory.
+type multipartBody struct {
+	parts    []types.AttachPart
+	boundary string
+	size     int64 // -1 when a part's size is unknown, which forces chunked encoding
+	reader   io.ReadCloser
+}
+
+// buildMultipartBody prepares a streaming multipart body for the attached parts.
+// Files are checked up front so a missing file fails before the request is sent.
+func (e *Executor) buildMultipartBody(bodyPlan *planner.BodyPlan) (io.Reader, string, error) {
+	boundary := bodyPlan.Boundary
+	if boundary == "" {
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+	}
+
+	body := &multipartBody{parts: bodyPlan.AttachParts, boundary: boundary}
+	size, err := body.measure()
+	if err != nil {
+		return nil, "", err
+	}
+	body.size = size
+
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return body, contentType, nil
+}
+
+// Read streams the body when it is used as a plain io.Reader.
+func (m *multipartBody) Read(p []byte) (int, error) {
+	if m.reader == nil {
+		m.reader = m.open()
+	}
+	return m.reader.Read(p)
+}
+
+// attach installs the body on req with its precomputed Content-Length (or chunked
+// encoding when unknown) and a GetBody that re-streams the parts for retries.
+func (m *multipartBody) attach(req *http.Request) {
+	req.ContentLength = m.size
+	req.Body = m.open()
+	req.GetBody = nil
+	if !m.readsStdin() {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return m.open(), nil
+		}
+	}
+}
+
+// open returns a reader for the multipart body. Nothing is written until the
+// first Read, so a request that is built but never sent leaves no goroutine or
+// open file behind.
+func (m *multipartBody) open() io.ReadCloser {
+	return &multipartStream{body: m}
+}
+
+// multipartStream writes its body into a pipe on a goroutine started by the first
+// Read. Close stops the writer, which then closes any open file.
+type multipartStream struct {
+	body   *multipartBody
+	once   sync.Once
+	pipe   *io.PipeReader
+	reader io.Reader
+}
+
+func (s *multipartStream) start() {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.body.writeTo(pw))
+	}()
+	s.pipe, s.reader = pr, pr
+
+	// Show an upload progress bar when stderr is a TTY
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		s.reader = newProgressReader(pr, 0, s.body.size, os.Stderr)
+	}
+}
+
+func (s *multipartStream) Read(p []byte) (int, error) {
+	s.once.Do(s.start)
+	return s.reader.Read(p)
+}
+
+func (s *multipartStream) Close() error {
+	// Starting here on a never-read stream would spawn a writer just to stop it
+	s.once.Do(func() {})
+	if s.pipe != nil {
+		return s.pipe.Close()
+	}
+	return nil
+}
+
+// writeTo writes the complete multipart body to w.
+func (m *multipartBody) writeTo(w io.Writer) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+
+	for _, part := range m.parts {
+		partWriter, err := writer.CreatePart(partHeader(part))
+		if err != nil {
+			return fmt.Errorf("failed to create form field: %w", err)
+		}
+
+		if part.FilePath == "" {
+			if _, err := io.WriteString(partWriter, part.Value); err != nil {
+				return fmt.Errorf("failed to write value: %w", err)
+			}
+			continue
+		}
+
+		file, err := openPartFile(part.FilePath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(partWriter, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write file data: %w", err)
+		}
+	}
+
+	return writer.Close()
+}
+
+// measure computes the encoded body size without reading any file contents.
+// It returns -1 if any attached file has no size known in advance (stdin, pipes).
+func (m *multipartBody) measure() (int64, error) {
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	if err := writer.SetBoundary(m.boundary); err != nil {
+		return 0, fmt.Errorf("invalid boundary: %w", err)
+	}
+
+	var fileBytes int64
+	known := true
+	for _, part := range m.parts {
+		partWriter, err := writer.CreatePart(partHeader(part))
+		if err != nil {
+			return 0, fmt.Errorf("failed to create form field: %w", err)
+		}
+		if part.FilePath == "" {
+			io.WriteString(partWriter, part.Value)
+			continue
+		}
+		if part.FilePath == "-" {
+			known = false
+			continue
+		}
+
+		info, err := os.Stat(part.FilePath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read file %s: %w", part.FilePath, err)
+		}
+		if !info.Mode().IsRegular() {
+			known = false
+			continue
+		}
+		fileBytes += info.Size()
+	}
+	writer.Close()
+
+	if !known {
+		return -1, nil
+	}
+	return counter.n + fileBytes, nil
+}
+
+// readsStdin reports whether any part streams from stdin, which cannot be replayed.
+func (m *multipartBody) readsStdin() bool {
+	for _, part := range m.parts {
+		if part.FilePath == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// partHeader builds the MIME headers for a part. File parts default their filename
+// to the file's base name and their Content-Type to one inferred from the extension.
+func partHeader(part types.AttachPart) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(part.Name))
+
+	filename := part.Filename
+	if filename == "" && part.FilePath != "" && part.FilePath != "-" {
+		filename = filepath.Base(part.FilePath)
+	}
+	if filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+	}
+	header.Set("Content-Disposition", disposition)
+
+	contentType := part.Type
+	if contentType == "" && part.FilePath != "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return header
+}
+
+// openPartFile opens an attached file, treating "-" as stdin.
+func openPartFile(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a Content-Disposition parameter value the way mime/multipart does.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// countingWriter counts bytes written to it and discards them.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
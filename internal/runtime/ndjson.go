@@ -0,0 +1,48 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// decodeJSONStream decodes one or more concatenated JSON values, such as a single
+// document or an NDJSON response.
+func decodeJSONStream(body []byte) ([]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var values []interface{}
+	for {
+		value, err := decodeOrderedValue(decoder)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return values, nil
+}
+
+// writeNDJSON writes one compact JSON value per line. A single top-level array
+// is split into its elements; other values are written as they are.
+func writeNDJSON(w io.Writer, values []interface{}) error {
+	if len(values) == 1 {
+		if arr, ok := values[0].([]interface{}); ok {
+			values = arr
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, value := range values {
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adammpkins/req/internal/session"
+)
+
+// tokenResponseLimit bounds how much of a token endpoint's response is read.
+const tokenResponseLimit = 1 << 20
+
+// refreshToken renews the session's OAuth2 access token at its token endpoint and
+// saves the session. On failure the current token is kept and a warning printed,
+// so the request still goes out and the server decides whether to accept it.
+func (e *Executor) refreshToken(sess *session.Session, host string) {
+	form, ok := sess.OAuth.RenewalForm()
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: access token for %s has expired and cannot be refreshed; run authenticate again\n", host)
+		return
+	}
+
+	token, err := e.requestToken(sess.OAuth, form.Encode())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to refresh access token for %s: %v\n", host, err)
+		return
+	}
+	sess.ApplyRefresh(token, time.Now())
+	if err := session.SaveSession(sess); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: refreshed access token for %s but could not save it: %v\n", host, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Refreshed access token for %s\n", host)
+}
+
+// requestToken posts a token request to the endpoint, authenticating with the
+// client credentials stored with the token, and parses the response.
+func (e *Executor) requestToken(oauth *session.OAuth, form string) (*session.TokenResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, oauth.TokenEndpoint, strings.NewReader(form))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if oauth.ClientSecret != "" {
+		req.SetBasicAuth(oauth.ClientID, oauth.ClientSecret)
+	}
+
+	resp, err := e.tokenClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, tokenResponseLimit))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+	token, ok := session.ParseTokenResponse(body)
+	if !ok {
+		return nil, fmt.Errorf("token endpoint response has no access_token")
+	}
+	return token, nil
+}
@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// orderedObject is a decoded JSON object that remembers the order of its keys,
+// so picked, tabular and re-encoded output follow the server's field order.
+// It implements jsonpath.Object.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// Keys returns the object's keys in document order.
+func (o *orderedObject) Keys() []string {
+	return o.keys
+}
+
+// Get returns the value stored under key.
+func (o *orderedObject) Get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// MarshalJSON encodes the object with its keys in their original order. HTML
+// characters are left unescaped; the outer encoder applies its own escaping.
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline
+		buf.WriteByte(':')
+		if err := encoder.Encode(o.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrderedJSON decodes a JSON document with numbers as json.Number and
+// objects as *orderedObject values.
+func decodeOrderedJSON(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// decodeOrderedValue decodes the next value from decoder. It returns io.EOF only
+// when the input ends cleanly before the value starts.
+func decodeOrderedValue(decoder *json.Decoder) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &orderedObject{values: make(map[string]interface{})}
+		for decoder.More() {
+			keyTok, err := decoder.Token()
+			if err != nil {
+				return nil, truncated(err)
+			}
+			key := keyTok.(string)
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, truncated(err)
+			}
+			// A repeated key keeps its first position and its last value
+			if _, seen := obj.values[key]; !seen {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = value
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, truncated(err)
+		}
+		return obj, nil
+
+	case json.Delim('['):
+		arr := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, truncated(err)
+			}
+			arr = append(arr, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, truncated(err)
+		}
+		return arr, nil
+	}
+
+	return tok, nil
+}
+
+// truncated reports an input that ends inside a value as io.ErrUnexpectedEOF.
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
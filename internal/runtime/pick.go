@@ -0,0 +1,32 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// pickJSON applies a pick= JSONPath to a JSON body. Scalar results are returned as
+// raw text (strings unquoted) with scalar set; objects and arrays are re-encoded as
+// indented JSON for the output formatter.
+func pickJSON(body []byte, expr string) ([]byte, bool, error) {
+	data, err := decodeOrderedJSON(body)
+	if err != nil {
+		return nil, false, fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	value, err := lookupJSONPath(data, expr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch value.(type) {
+	case *orderedObject, []interface{}:
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, false, err
+		}
+		return append(out, '\n'), false, nil
+	default:
+		return []byte(formatJSONValue(value) + "\n"), true, nil
+	}
+}
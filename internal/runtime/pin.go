@@ -0,0 +1,49 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// pinMismatchError reports a server whose certificate key matches none of the pins.
+type pinMismatchError struct {
+	host     string
+	observed string
+	pins     []string
+}
+
+func (e *pinMismatchError) Error() string {
+	host := ""
+	if e.host != "" {
+		host = " for " + e.host
+	}
+	return fmt.Sprintf("public key pin mismatch%s: server presented %s, expected %s", host, e.observed, strings.Join(e.pins, " or "))
+}
+
+// publicKeyPin returns the "sha256//" pin of a certificate: the base64 SHA-256 hash
+// of its SubjectPublicKeyInfo, as used by curl's --pinnedpubkey.
+func publicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256//" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPins returns a VerifyConnection hook that requires the server's leaf
+// certificate key to match one of pins. It runs after the usual chain and host
+// name checks, so pinning narrows trust rather than replacing it.
+func verifyPins(pins []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return &pinMismatchError{host: cs.ServerName, observed: "no certificate", pins: pins}
+		}
+		observed := publicKeyPin(cs.PeerCertificates[0])
+		if slices.Contains(pins, observed) {
+			return nil
+		}
+		return &pinMismatchError{host: cs.ServerName, observed: observed, pins: pins}
+	}
+}
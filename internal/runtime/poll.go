@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/mattn/go-isatty"
+)
+
+// pollWatch re-issues the request every plan.Poll.Interval, printing the response
+// whenever it changes. It stops with exit code 0 once the until= predicate passes,
+// and with exit code 6 if the watch deadline (under=) expires first.
+func (e *Executor) pollWatch(req *http.Request, plan *planner.ExecutionPlan) error {
+	ctx := req.Context()
+	tty := isatty.IsTerminal(os.Stdout.Fd())
+
+	var lastStatus int
+	var lastBody []byte
+	for polls := 1; ; polls++ {
+		resp, body, err := e.poll(ctx, req, plan)
+		if err == nil {
+			if polls == 1 || resp.StatusCode != lastStatus || !bytes.Equal(body, lastBody) {
+				if err := e.writePollResult(resp, body, plan, tty); err != nil {
+					return err
+				}
+				lastStatus, lastBody = resp.StatusCode, body
+			}
+
+			if plan.Poll.Until != nil && e.runExpectCheck(resp, body, *plan.Poll.Until) == nil {
+				fmt.Fprintf(os.Stderr, "Condition met after %d poll(s)\n", polls)
+				return nil
+			}
+		} else {
+			if ctx.Err() != nil {
+				return e.pollDeadlineError(plan)
+			}
+			// A pinned key that doesn't match won't start matching on a later poll
+			var pinErr *pinMismatchError
+			if errors.As(err, &pinErr) {
+				return requestFailedError(err, 1)
+			}
+			fmt.Fprintf(os.Stderr, "Poll failed: %v\n", err)
+		}
+
+		if !sleepContext(ctx, plan.Poll.Interval) {
+			return e.pollDeadlineError(plan)
+		}
+	}
+}
+
+// poll issues one poll and reads its body. With under= the watch deadline in ctx
+// bounds each poll; without it a poll gives up after the default request timeout,
+// so a stalled response doesn't hold an open-ended watch.
+func (e *Executor) poll(ctx context.Context, req *http.Request, plan *planner.ExecutionPlan) (*http.Response, []byte, error) {
+	if plan.Timeout == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	resp, _, err := e.executeWithRedirects(req.Clone(ctx), plan)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, _, err := e.readAndDecompress(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// pollDeadlineError reports the end of a polling watch whose deadline expired.
+// Without an until= predicate, reaching the deadline is the expected way to stop.
+func (e *Executor) pollDeadlineError(plan *planner.ExecutionPlan) error {
+	if plan.Poll.Until == nil {
+		return nil
+	}
+	if plan.Timeout == nil {
+		return &ExecutionError{Code: 6, Message: "watch ended before condition was met"}
+	}
+	return &ExecutionError{Code: 6, Message: fmt.Sprintf("watch deadline of %s expired before condition was met", *plan.Timeout)}
+}
+
+// writePollResult prints a changed poll response: prefixed with a timestamped status
+// line on a TTY, and newline-terminated so consecutive results stay line-separated.
+func (e *Executor) writePollResult(resp *http.Response, body []byte, plan *planner.ExecutionPlan, tty bool) error {
+	if tty {
+		fmt.Fprintf(os.Stdout, "[%s] HTTP %d\n", time.Now().Format("15:04:05"), resp.StatusCode)
+	}
+	if err := e.writeOutput(body, resp.Header.Get("Content-Type"), plan.Output); err != nil {
+		return err
+	}
+	// Output re-encoded from JSON already ends with a newline
+	reencoded := plan.Output != nil && json.Valid(body) &&
+		(plan.Output.Format == "json" || plan.Output.Format == "yaml" || plan.Output.Format == "ndjson")
+	if len(body) > 0 && body[len(body)-1] != '\n' && !reencoded {
+		_, err := os.Stdout.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
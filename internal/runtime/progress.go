@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// progressRedraw limits how often the progress bar is redrawn.
+const progressRedraw = 100 * time.Millisecond
+
+// progressWidth is the number of cells in the progress bar.
+const progressWidth = 30
+
+// progressReader counts bytes flowing through r and draws a progress bar with
+// transfer rate and ETA to out. A total of -1 means the size is unknown.
+type progressReader struct {
+	r        io.Reader
+	out      io.Writer
+	total    int64
+	done     int64
+	offset   int64 // bytes already present before this transfer (resumed downloads)
+	start    time.Time
+	lastDraw time.Time
+	finished bool
+}
+
+// newProgressReader wraps r, reporting progress from offset against total (or -1 if unknown) to out.
+func newProgressReader(r io.Reader, offset, total int64, out io.Writer) *progressReader {
+	return &progressReader{r: r, out: out, total: total, done: offset, offset: offset, start: time.Now()}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if err == io.EOF {
+		p.finish()
+	} else if now := time.Now(); now.Sub(p.lastDraw) >= progressRedraw {
+		p.lastDraw = now
+		p.draw()
+	}
+	return n, err
+}
+
+// finish draws the final state and ends the progress line. It is safe to call more than once.
+func (p *progressReader) finish() {
+	if p.finished {
+		return
+	}
+	p.finished = true
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+// draw renders the current progress on a single, carriage-return-refreshed line.
+func (p *progressReader) draw() {
+	elapsed := time.Since(p.start).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.done-p.offset) / elapsed
+	}
+
+	var line string
+	if p.total > 0 {
+		fraction := float64(p.done) / float64(p.total)
+		if fraction > 1 {
+			fraction = 1
+		}
+		filled := int(fraction * progressWidth)
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+		eta := "--"
+		if rate > 0 && p.done < p.total {
+			eta = (time.Duration(float64(p.total-p.done)/rate) * time.Second).Round(time.Second).String()
+		} else if p.done >= p.total {
+			eta = "0s"
+		}
+		line = fmt.Sprintf("[%s] %3.0f%% %s / %s  %s/s  ETA %s",
+			bar, fraction*100, formatBytes(p.done), formatBytes(p.total), formatBytes(int64(rate)), eta)
+	} else {
+		line = fmt.Sprintf("%s  %s/s", formatBytes(p.done), formatBytes(int64(rate)))
+	}
+
+	// \033[K clears leftovers from a previously longer line
+	fmt.Fprintf(p.out, "\r%s\033[K", line)
+}
+
+// formatBytes renders a byte count using the same binary units accepted by under=.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		if value < unit || suffix == "TB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resumeState records the validators of the response a partial download came
+// from, so a later attempt can ask for the rest only if the resource is unchanged.
+type resumeState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// partialPath returns where a resumable download of destination is accumulated.
+func partialPath(destination string) string {
+	return destination + ".part"
+}
+
+// resumeStatePath returns the sidecar file holding the partial download's validators.
+func resumeStatePath(destination string) string {
+	return destination + ".part.json"
+}
+
+// prepareResume adds Range and If-Range headers when a partial download of
+// destination exists from an earlier attempt at the same URL, and returns the
+// byte offset being resumed from (0 when starting fresh).
+func (e *Executor) prepareResume(req *http.Request, destination string) int64 {
+	// Byte ranges must refer to the stored bytes, not a compressed representation
+	req.Header.Set("Accept-Encoding", "identity")
+
+	info, err := os.Stat(partialPath(destination))
+	if err != nil || info.Size() == 0 {
+		return 0
+	}
+
+	state, err := loadResumeState(destination)
+	if err != nil || state.URL != req.URL.String() {
+		fmt.Fprintf(os.Stderr, "Ignoring partial download %s from a different or unknown source\n", partialPath(destination))
+		return 0
+	}
+
+	offset := info.Size()
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	// Weak ETags cannot be used with If-Range, so fall back to Last-Modified
+	switch {
+	case state.ETag != "" && !strings.HasPrefix(state.ETag, "W/"):
+		req.Header.Set("If-Range", state.ETag)
+	case state.LastModified != "":
+		req.Header.Set("If-Range", state.LastModified)
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: no ETag or Last-Modified stored; cannot verify the resource is unchanged\n")
+	}
+
+	fmt.Fprintf(os.Stderr, "Resuming download at %d bytes\n", offset)
+	return offset
+}
+
+// writePartialFile writes body into the partial file for destination: appending
+// on 206 Partial Content, or starting over on a full 200 response. The partial
+// file is kept on failure so the download can be resumed again.
+func writePartialFile(resp *http.Response, body io.Reader, destination string, offset int64) (string, int64, error) {
+	path := partialPath(destination)
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resp.StatusCode == http.StatusPartialContent {
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return path, 0, err
+		}
+		if start != offset {
+			return path, 0, fmt.Errorf("server resumed at byte %d, expected %d", start, offset)
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	} else {
+		if offset > 0 {
+			fmt.Fprintf(os.Stderr, "Resource changed or range not supported; restarting download\n")
+		}
+		offset = 0
+
+		dir := filepath.Dir(destination)
+		if dir != "." && dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return path, 0, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		// Key the state by the URL that was requested, not where redirects ended up
+		state := resumeState{
+			URL:          originalURL(resp.Request),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if err := saveResumeState(destination, state); err != nil {
+			return path, 0, err
+		}
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return path, 0, fmt.Errorf("failed to open partial file: %w", err)
+	}
+	written, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return path, offset + written, err
+}
+
+// originalURL walks back through redirects to the URL of the first request.
+func originalURL(req *http.Request) string {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req.URL.String()
+}
+
+// contentRangeStart parses the first byte position of a "bytes start-end/size" Content-Range.
+func contentRangeStart(value string) (int64, error) {
+	spec, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	startStr, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startStr), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	return start, nil
+}
+
+// loadResumeState reads the validators stored alongside a partial download.
+func loadResumeState(destination string) (*resumeState, error) {
+	data, err := os.ReadFile(resumeStatePath(destination))
+	if err != nil {
+		return nil, err
+	}
+	var state resumeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// saveResumeState stores the validators for a partial download.
+func saveResumeState(destination string, state resumeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(resumeStatePath(destination), data, 0644); err != nil {
+		return fmt.Errorf("failed to save resume state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/adammpkins/req/internal/planner"
+)
+
+// sendFunc performs a single attempt of a request, including any redirect handling.
+type sendFunc func(req *http.Request) (*http.Response, error)
+
+// executeWithRetry runs send, retrying transient failures according to plan.Retry.
+// It returns the final response (which may itself carry a retryable status once
+// attempts are exhausted) and the number of attempts made.
+func (e *Executor) executeWithRetry(req *http.Request, plan *planner.ExecutionPlan, send sendFunc) (*http.Response, int, error) {
+	maxAttempts := 1
+	if plan.Retry != nil && plan.Retry.Count > 0 {
+		maxAttempts += plan.Retry.Count
+		if !canRetryRequest(req, plan.Retry) {
+			maxAttempts = 1
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := send(req)
+		if attempt >= maxAttempts {
+			return resp, attempt, err
+		}
+
+		var reason string
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if !isRetryableError(err) || req.Context().Err() != nil {
+				return nil, attempt, err
+			}
+			reason = err.Error()
+		case isRetryableStatus(resp.StatusCode):
+			reason = resp.Status
+			delay = retryAfter(resp.Header.Get("Retry-After"))
+			// Don't let a server park the command for hours
+			if delay > plan.Retry.Backoff.Max {
+				delay = plan.Retry.Backoff.Max
+			}
+			// Drain so the connection can be reused for the next attempt
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, attempt, nil
+		}
+
+		if delay == 0 {
+			delay = backoffDelay(plan.Retry.Backoff, attempt)
+		}
+		fmt.Fprintf(os.Stderr, "Attempt %d/%d failed (%s), retrying in %s\n", attempt, maxAttempts, reason, delay.Round(time.Millisecond))
+		if !sleepContext(req.Context(), delay) {
+			return nil, attempt, req.Context().Err()
+		}
+
+		// Rewind the body for the next attempt
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, attempt, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// requestFailedError wraps a transport error, noting the attempt count when retries were made.
+// A public key pin mismatch gets its own exit code.
+func requestFailedError(err error, attempts int) error {
+	var pinErr *pinMismatchError
+	if errors.As(err, &pinErr) {
+		return &ExecutionError{Code: 8, Message: pinErr.Error()}
+	}
+	if attempts > 1 {
+		return &ExecutionError{Code: 4, Message: fmt.Sprintf("request failed after %d attempts: %v", attempts, err)}
+	}
+	return &ExecutionError{Code: 4, Message: fmt.Sprintf("request failed: %v", err)}
+}
+
+// canRetryRequest reports whether the request may be safely re-sent.
+// POST and PATCH are not idempotent, so they are only retried with retry=N:always,
+// and bodies that cannot be rewound are never retried.
+func canRetryRequest(req *http.Request, retry *planner.RetryPlan) bool {
+	if (req.Method == http.MethodPost || req.Method == http.MethodPatch) && !retry.AllowNonIdempotent {
+		fmt.Fprintf(os.Stderr, "Note: not retrying non-idempotent %s (use retry=%d:always to allow)\n", req.Method, retry.Count)
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		fmt.Fprintf(os.Stderr, "Note: not retrying, request body cannot be replayed\n")
+		return false
+	}
+	return true
+}
+
+// isRetryableStatus reports whether an HTTP status indicates a transient failure.
+// Other 5xx statuses, such as 501 Not Implemented, won't change on retry.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRetryableError reports whether a transport error is transient: a timeout, a
+// refused or reset connection, or a connection closed early. Failures that won't
+// change on retry, such as an unknown host or a certificate that fails
+// verification or its pin, are not retried.
+func isRetryableError(err error) bool {
+	var pinErr *pinMismatchError
+	if errors.As(err, &pinErr) {
+		return false
+	}
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	// url.Error implements net.Error itself, so inspect what it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryAfter parses a Retry-After header given as seconds or an HTTP date.
+func retryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// backoffDelay returns an exponentially growing, jittered delay within the backoff range.
+// The ceiling doubles from Min on each attempt up to Max; the delay is drawn from [Min, ceiling].
+func backoffDelay(backoff planner.BackoffRange, attempt int) time.Duration {
+	ceiling := backoff.Min
+	for i := 1; i < attempt && ceiling < backoff.Max; i++ {
+		ceiling *= 2
+	}
+	if ceiling > backoff.Max {
+		ceiling = backoff.Max
+	}
+	if ceiling <= backoff.Min {
+		return backoff.Min
+	}
+	return backoff.Min + time.Duration(rand.Int63n(int64(ceiling-backoff.Min)+1))
+}
@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/types"
+	"github.com/mattn/go-isatty"
+)
+
+// saveResponse streams the response body to plan.Output.Destination without
+// buffering it in memory. The body is written to a temporary file next to the
+// destination and only renamed into place once the download and any expect
+// checks succeed, so an existing file is never left half-overwritten. With
+// resume, the body goes to a persistent partial file instead, continuing from
+// offset when the server answers 206.
+func (e *Executor) saveResponse(resp *http.Response, reqURL string, plan *planner.ExecutionPlan, offset int64) error {
+	destination := plan.Output.Destination
+	if plan.Resume && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return &ExecutionError{Code: 4, Message: fmt.Sprintf("HTTP 416: server cannot resume from byte %d; %s may already be complete or the resource shrank (delete it to start over)", offset, partialPath(destination))}
+	}
+
+	// Without expect checks, HTTP errors fail before anything touches the disk
+	if len(plan.Expect) == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		e.printErrorMeta(resp, reqURL)
+		return &ExecutionError{Code: 4, Message: fmt.Sprintf("HTTP %d %s", resp.StatusCode, resp.Status)}
+	}
+
+	// A resumed download continues the partial file, which counts against under=
+	var written int64
+	if plan.Resume && resp.StatusCode == http.StatusPartialContent {
+		written = offset
+	}
+
+	// Progress is measured on the wire, so it matches Content-Length even for compressed bodies
+	var progress *progressReader
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		total := resp.ContentLength
+		if resp.StatusCode != http.StatusPartialContent {
+			offset = 0
+		} else if total >= 0 {
+			total += offset
+		}
+		progress = newProgressReader(resp.Body, offset, total, os.Stderr)
+		resp.Body = io.NopCloser(progress)
+	}
+
+	body, decompressed, err := e.decompressReaderAt(resp, written)
+	if err != nil {
+		if plan.Resume {
+			// Resuming cannot help an oversized download, so drop what was written
+			var limitErr *sizeLimitError
+			if errors.As(err, &limitErr) {
+				os.Remove(partialPath(destination))
+				os.Remove(resumeStatePath(destination))
+			}
+		}
+		return readError(err)
+	}
+	defer body.Close()
+
+	var tmpPath string
+	var size int64
+	if plan.Resume {
+		tmpPath, size, err = writePartialFile(resp, body, destination, offset)
+	} else {
+		tmpPath, size, err = writeTempFile(body, destination)
+	}
+	if progress != nil {
+		progress.finish()
+	}
+	if err != nil {
+		var limitErr *sizeLimitError
+		if errors.As(err, &limitErr) {
+			// Resuming cannot help an oversized download, so drop what was written
+			if plan.Resume {
+				os.Remove(tmpPath)
+				os.Remove(resumeStatePath(destination))
+			}
+			return readError(err)
+		}
+		if plan.Resume {
+			return &ExecutionError{Code: 4, Message: fmt.Sprintf("failed to save response: %v (partial download kept in %s; rerun with resume to continue)", err, tmpPath)}
+		}
+		return &ExecutionError{Code: 4, Message: fmt.Sprintf("failed to save response: %v", err)}
+	}
+	// Removing is a no-op once the file has been renamed into place. A resumed
+	// download that fails its checks is dropped too: it is complete, so resuming
+	// it again could only get a 416, and the next run should start over.
+	defer os.Remove(tmpPath)
+	if plan.Resume {
+		defer os.Remove(resumeStatePath(destination))
+	}
+
+	if decompressed {
+		fmt.Fprintf(os.Stderr, "Decompressed response\n")
+	}
+	e.printMeta(resp, reqURL, int(size), decompressed)
+
+	if len(plan.Expect) > 0 {
+		// Body checks re-read the saved file rather than holding the download in memory
+		var bodyBytes []byte
+		if expectNeedsBody(plan.Expect) {
+			bodyBytes, err = os.ReadFile(tmpPath)
+			if err != nil {
+				return fmt.Errorf("failed to read saved file: %w", err)
+			}
+		}
+		if err := e.runExpectChecks(resp, bodyBytes, plan.Expect); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return &ExecutionError{Code: 3, Message: "expectation failed"}
+		}
+	}
+
+	if err := os.Rename(tmpPath, destination); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
+
+// printErrorMeta prints metadata for an error response that is not saved,
+// discarding its body to measure it.
+func (e *Executor) printErrorMeta(resp *http.Response, reqURL string) {
+	size := int64(-1)
+	body, decompressed, err := e.decompressReader(resp)
+	if err == nil {
+		if n, err := io.Copy(io.Discard, body); err == nil {
+			size = n
+		}
+		body.Close()
+	}
+	e.printMeta(resp, reqURL, int(size), decompressed)
+}
+
+// writeTempFile copies body into a new temporary file in the destination's
+// directory (so the final rename stays on one filesystem) and returns its path
+// and size. The temporary file is removed if the copy fails.
+func writeTempFile(body io.Reader, destination string) (string, int64, error) {
+	dir := filepath.Dir(destination)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", 0, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(destination)+".*.part")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	size, err := io.Copy(file, body)
+	if err == nil {
+		// CreateTemp uses 0600; saved files get the usual permissions
+		err = file.Chmod(0644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, err
+	}
+	return file.Name(), size, nil
+}
+
+// expectNeedsBody reports whether any check inspects the response body.
+func expectNeedsBody(checks []types.ExpectCheck) bool {
+	for _, check := range checks {
+		if check.Type != "status" && check.Type != "header" {
+			return true
+		}
+	}
+	return false
+}
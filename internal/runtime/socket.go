@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/session"
+)
+
+// socketDialer returns a DialContext that connects to the Unix socket at socketPath
+// for the target URL's host and port. Other addresses, such as redirects to another
+// host, are passed to next.
+func socketDialer(socketPath, target string, next dialFunc) (dialFunc, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	socketAddr := hostPort(u)
+
+	var dialer net.Dialer
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == socketAddr {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		return next(ctx, network, addr)
+	}, nil
+}
+
+// hostPort returns the host:port the transport dials for u, filling in the
+// scheme's default port.
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// sessionHost returns the key the plan's session is stored under: the socket for
+// Unix socket requests, otherwise the URL's host.
+func sessionHost(plan *planner.ExecutionPlan) (string, error) {
+	if plan.Socket != "" {
+		return session.SocketHost(plan.Socket), nil
+	}
+	return session.ExtractHost(plan.URL)
+}
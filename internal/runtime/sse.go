@@ -0,0 +1,245 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/mattn/go-isatty"
+)
+
+// defaultSSERetry is the reconnection delay used until the server sends a retry: field.
+const defaultSSERetry = 3 * time.Second
+
+// maxSSELine bounds a single event-stream line so a misbehaving server cannot exhaust memory.
+const maxSSELine = 16 * 1024 * 1024
+
+// sseEvent represents a single dispatched Server-Sent Event.
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// sseParser incrementally parses text/event-stream lines into events.
+type sseParser struct {
+	eventType string
+	data      strings.Builder
+	hasData   bool
+	lastID    string
+	retry     time.Duration
+}
+
+// feed processes one line of the stream and returns an event when a blank line dispatches one.
+func (p *sseParser) feed(line string) (sseEvent, bool) {
+	if line == "" {
+		return p.dispatch()
+	}
+
+	// Lines starting with a colon are comments (often used as keep-alives)
+	if strings.HasPrefix(line, ":") {
+		return sseEvent{}, false
+	}
+
+	field, value := line, ""
+	if idx := strings.Index(line, ":"); idx >= 0 {
+		field = line[:idx]
+		value = strings.TrimPrefix(line[idx+1:], " ")
+	}
+
+	switch field {
+	case "event":
+		p.eventType = value
+	case "data":
+		if p.hasData {
+			p.data.WriteByte('\n')
+		}
+		p.data.WriteString(value)
+		p.hasData = true
+	case "id":
+		// IDs containing NULL are ignored per the SSE specification
+		if !strings.ContainsRune(value, 0) {
+			p.lastID = value
+		}
+	case "retry":
+		if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+			p.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return sseEvent{}, false
+}
+
+// dispatch emits the buffered event and resets the per-event state.
+func (p *sseParser) dispatch() (sseEvent, bool) {
+	defer func() {
+		p.eventType = ""
+		p.data.Reset()
+		p.hasData = false
+	}()
+
+	if !p.hasData {
+		return sseEvent{}, false
+	}
+
+	eventType := p.eventType
+	if eventType == "" {
+		eventType = "message"
+	}
+	return sseEvent{ID: p.lastID, Type: eventType, Data: p.data.String()}, true
+}
+
+// scanSSELines is a bufio.SplitFunc that accepts CRLF, LF, or bare CR line endings.
+func scanSSELines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			// A CR at the end of the buffer may be the first half of CRLF
+			if i+1 == len(data) && !atEOF {
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// isEventStream reports whether the response carries a text/event-stream body.
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// readEvents reads events from body until it ends, writing each one as it is dispatched.
+func (e *Executor) readEvents(body io.Reader, parser *sseParser, tty bool) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELine)
+	scanner.Split(scanSSELines)
+
+	for scanner.Scan() {
+		event, ok := parser.feed(scanner.Text())
+		if !ok {
+			continue
+		}
+		if err := writeEvent(os.Stdout, event, tty); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// writeEvent prints an event: timestamped with its type on a TTY, raw data lines otherwise.
+func writeEvent(w io.Writer, event sseEvent, tty bool) error {
+	if !tty {
+		_, err := fmt.Fprintf(w, "%s\n", event.Data)
+		return err
+	}
+
+	prefix := time.Now().Format("15:04:05")
+	if event.Type != "message" {
+		prefix += " " + event.Type
+	}
+	for _, line := range strings.Split(event.Data, "\n") {
+		if _, err := fmt.Fprintf(w, "[%s] %s\n", prefix, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchEvents streams a text/event-stream response to stdout, reconnecting with
+// Last-Event-ID when the connection drops until the server answers 204 or the
+// watch deadline (under=) expires.
+func (e *Executor) watchEvents(req *http.Request, resp *http.Response, plan *planner.ExecutionPlan) error {
+	ctx := req.Context()
+	tty := isatty.IsTerminal(os.Stdout.Fd())
+	parser := &sseParser{retry: defaultSSERetry}
+
+	for {
+		body, _, err := e.decompressReader(resp)
+		if err == nil {
+			err = e.readEvents(body, parser, tty)
+			body.Close()
+		}
+		resp.Body.Close()
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Reconnecting would only hit the same limit again
+		var limitErr *sizeLimitError
+		if errors.As(err, &limitErr) {
+			return readError(err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Stream interrupted: %v\n", err)
+		}
+
+		// Reconnect until a new stream is established or the watch is over
+		for {
+			if parser.lastID != "" {
+				fmt.Fprintf(os.Stderr, "Reconnecting in %s (Last-Event-ID: %s)\n", parser.retry, parser.lastID)
+			} else {
+				fmt.Fprintf(os.Stderr, "Reconnecting in %s\n", parser.retry)
+			}
+			if !sleepContext(ctx, parser.retry) {
+				return nil
+			}
+
+			next := req.Clone(ctx)
+			if parser.lastID != "" {
+				next.Header.Set("Last-Event-ID", parser.lastID)
+			}
+
+			resp, _, err = e.executeWithRedirects(next, plan)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				fmt.Fprintf(os.Stderr, "Reconnect failed: %v\n", err)
+				continue
+			}
+			break
+		}
+
+		// 204 No Content is the server's way of telling clients to stop reconnecting
+		if resp.StatusCode == http.StatusNoContent {
+			resp.Body.Close()
+			fmt.Fprintf(os.Stderr, "Stream closed by server\n")
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+			resp.Body.Close()
+			return &ExecutionError{Code: 4, Message: fmt.Sprintf("event stream ended: HTTP %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))}
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done, reporting whether the full wait elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/adammpkins/req/internal/tui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	// maxCellWidth caps a column's width before fitting the table to the terminal.
+	maxCellWidth = 40
+	// minColumnWidth is the narrowest a column is squeezed to when fitting.
+	minColumnWidth = 4
+	// columnGap separates adjacent columns.
+	columnGap = "  "
+)
+
+// writeTable renders body as a column-aligned table, built like as=csv output.
+// Long cells are truncated with an ellipsis and, when width > 0, the widest
+// columns are narrowed until the table fits. styled adds lipgloss colors.
+func writeTable(w io.Writer, body []byte, contentType string, columns []string, styled bool, width int) error {
+	t, err := buildTable(body, contentType, columns)
+	if err != nil {
+		return err
+	}
+	if len(t.header) == 0 {
+		return nil
+	}
+
+	header := sanitizeCells(t.header)
+	rows := make([][]string, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = sanitizeCells(row)
+	}
+
+	widths := columnWidths(header, rows)
+	fitWidths(widths, width)
+	numeric := numericColumns(len(header), rows)
+
+	rule := make([]string, len(widths))
+	for i, w := range widths {
+		rule[i] = strings.Repeat("-", w)
+		if styled {
+			rule[i] = strings.Repeat("─", w)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(formatTableRow(header, widths, nil, styledOr(styled, styles.TableHeader.Render)))
+	out.WriteString(formatTableRow(rule, widths, nil, styledOr(styled, styles.TableRule.Render)))
+	for _, row := range rows {
+		out.WriteString(formatTableRow(row, widths, numeric, nil))
+	}
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// terminalWidth returns the width tables should fit: $COLUMNS if set, else the
+// terminal's width when stdout is a TTY, else 0 for no limit.
+func terminalWidth(tty bool) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if tty {
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			return w
+		}
+	}
+	return 0
+}
+
+// sanitizeCells replaces control characters (newlines, tabs, escape sequences from
+// the response) with spaces so each row stays on one line.
+func sanitizeCells(cells []string) []string {
+	clean := make([]string, len(cells))
+	for i, cell := range cells {
+		clean[i] = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return ' '
+			}
+			return r
+		}, cell)
+	}
+	return clean
+}
+
+// columnWidths returns each column's display width, capped at maxCellWidth.
+func columnWidths(header []string, rows [][]string) []int {
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = ansi.StringWidth(cell)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+	for i := range widths {
+		widths[i] = min(widths[i], maxCellWidth)
+	}
+	return widths
+}
+
+// fitWidths narrows the widest columns one cell at a time until the table fits
+// in limit, leaving it wider only when every column is already at the minimum.
+func fitWidths(widths []int, limit int) {
+	if limit <= 0 {
+		return
+	}
+	total := len(columnGap) * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > limit {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// numericColumns marks columns whose non-empty cells are all numbers, which are
+// right-aligned.
+func numericColumns(n int, rows [][]string) []bool {
+	numeric := make([]bool, n)
+	for i := range numeric {
+		seen := false
+		numeric[i] = true
+		for _, row := range rows {
+			if row[i] == "" {
+				continue
+			}
+			seen = true
+			if _, err := strconv.ParseFloat(row[i], 64); err != nil {
+				numeric[i] = false
+				break
+			}
+		}
+		numeric[i] = numeric[i] && seen
+	}
+	return numeric
+}
+
+// formatTableRow truncates and pads cells to their column widths. render, if set,
+// styles each cell's text before padding so escape codes don't affect alignment.
+func formatTableRow(cells []string, widths []int, rightAlign []bool, render func(...string) string) string {
+	var line strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			line.WriteString(columnGap)
+		}
+		cell = ansi.Truncate(cell, widths[i], "…")
+		padding := strings.Repeat(" ", widths[i]-ansi.StringWidth(cell))
+		if render != nil {
+			cell = render(cell)
+		}
+		if rightAlign != nil && rightAlign[i] {
+			line.WriteString(padding + cell)
+		} else {
+			line.WriteString(cell + padding)
+		}
+	}
+	return strings.TrimRight(line.String(), " ") + "\n"
+}
+
+// styledOr returns render when output is styled and nil otherwise.
+func styledOr(styled bool, render func(...string) string) func(...string) string {
+	if styled {
+		return render
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package runtime
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timingRecorder collects a phase breakdown for each round trip of a request,
+// including every redirect hop, for the timing flag.
+type timingRecorder struct {
+	format string // "text", "json", or empty when only as=envelope reads the report
+
+	mu   sync.Mutex
+	hops []*hopTiming
+}
+
+// hopTiming holds the httptrace timestamps of one round trip. Phases that did not
+// happen, such as DNS on a reused connection, keep zero timestamps.
+type hopTiming struct {
+	method     string
+	url        string
+	status     int
+	reused     bool
+	remoteAddr string
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	end          time.Time
+}
+
+// hopReport is the phase breakdown of one hop, in milliseconds.
+type hopReport struct {
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status,omitempty"`
+	DNSMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TLSMs      float64 `json:"tls_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	TransferMs float64 `json:"transfer_ms"`
+	TotalMs    float64 `json:"total_ms"`
+	Reused     bool    `json:"reused"`
+}
+
+// timingReport is the breakdown of every hop of the final attempt.
+type timingReport struct {
+	Hops    []hopReport `json:"hops"`
+	TotalMs float64     `json:"total_ms"`
+}
+
+// timingTransport traces each round trip through next into rec.
+type timingTransport struct {
+	next http.RoundTripper
+	rec  *timingRecorder
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, hop := t.rec.trace(req)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.rec.finish(hop, nil)
+		return nil, err
+	}
+	t.rec.finish(hop, resp)
+	// The transfer phase ends when the body is fully read or closed
+	resp.Body = &timedBody{ReadCloser: resp.Body, done: func() { t.rec.mark(&hop.end, false) }}
+	return resp, nil
+}
+
+// trace starts a hop for req and returns req with httptrace hooks that fill it in.
+func (r *timingRecorder) trace(req *http.Request) (*http.Request, *hopTiming) {
+	hop := r.startHop(req)
+
+	// Happy eyeballs may resolve and dial more than once, so span first start to last done
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { r.mark(&hop.dnsStart, false) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { r.mark(&hop.dnsDone, true) },
+		ConnectStart:      func(string, string) { r.mark(&hop.connectStart, false) },
+		ConnectDone:       func(string, string, error) { r.mark(&hop.connectDone, true) },
+		TLSHandshakeStart: func() { r.mark(&hop.tlsStart, false) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { r.mark(&hop.tlsDone, true) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			hop.gotConn = time.Now()
+			hop.reused = info.Reused
+			if info.Conn != nil {
+				hop.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+			r.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { r.mark(&hop.wroteRequest, false) },
+		GotFirstResponseByte: func() { r.mark(&hop.firstByte, false) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), hop
+}
+
+// finish records the outcome of a hop's round trip. A nil resp means it failed,
+// which also ends the hop.
+func (r *timingRecorder) finish(hop *hopTiming, resp *http.Response) {
+	if resp == nil {
+		r.mark(&hop.end, false)
+		return
+	}
+	r.mu.Lock()
+	hop.status = resp.StatusCode
+	r.mu.Unlock()
+}
+
+// timedBody calls done once the body reaches EOF or is closed.
+type timedBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.done()
+	return b.ReadCloser.Close()
+}
+
+// startHop begins tracing a round trip for req.
+func (r *timingRecorder) startHop(req *http.Request) *hopTiming {
+	hop := &hopTiming{method: req.Method, url: req.URL.String(), start: time.Now()}
+	r.mu.Lock()
+	r.hops = append(r.hops, hop)
+	r.mu.Unlock()
+	return hop
+}
+
+// mark records the current time in field. Unless overwrite is set, only the first
+// call takes effect.
+func (r *timingRecorder) mark(field *time.Time, overwrite bool) {
+	now := time.Now()
+	r.mu.Lock()
+	if overwrite || field.IsZero() {
+		*field = now
+	}
+	r.mu.Unlock()
+}
+
+// reset discards the hops of an earlier attempt so only the final one is reported.
+func (r *timingRecorder) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.hops = nil
+	r.mu.Unlock()
+}
+
+// report returns the breakdown of the recorded hops. Hops still transferring are
+// measured up to now.
+func (r *timingRecorder) report() timingReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	report := timingReport{Hops: []hopReport{}}
+	for _, hop := range r.hops {
+		end := hop.end
+		if end.IsZero() {
+			end = now
+		}
+		// Time to first byte is measured from having a connection, so it covers
+		// sending the request and the server's processing time
+		ready := hop.gotConn
+		if ready.IsZero() {
+			ready = hop.start
+		}
+		report.Hops = append(report.Hops, hopReport{
+			Method:     hop.method,
+			URL:        hop.url,
+			Status:     hop.status,
+			DNSMs:      millis(hop.dnsStart, hop.dnsDone),
+			ConnectMs:  millis(hop.connectStart, hop.connectDone),
+			TLSMs:      millis(hop.tlsStart, hop.tlsDone),
+			TTFBMs:     millis(ready, hop.firstByte),
+			TransferMs: millis(hop.firstByte, end),
+			TotalMs:    millis(hop.start, end),
+			Reused:     hop.reused,
+		})
+	}
+	if len(r.hops) > 0 {
+		last := r.hops[len(r.hops)-1].end
+		if last.IsZero() {
+			last = now
+		}
+		report.TotalMs = millis(r.hops[0].start, last)
+	}
+	return report
+}
+
+// millis returns the time from start to end in milliseconds, or 0 if either is unset.
+func millis(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
+
+// print writes the timing report to w as a compact block, or a single JSON line
+// for timing=json. Recorders kept only for as=envelope have no format and print nothing.
+func (r *timingRecorder) print(w io.Writer) {
+	if r == nil || r.format == "" {
+		return
+	}
+	report := r.report()
+
+	if r.format == "json" {
+		data, err := json.Marshal(map[string]timingReport{"timing": report})
+		if err == nil {
+			fmt.Fprintf(w, "%s\n", data)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "Timing:\n")
+	for _, hop := range report.Hops {
+		status := "---"
+		if hop.Status != 0 {
+			status = fmt.Sprintf("%d", hop.Status)
+		}
+		conn := "new connection"
+		if hop.Reused {
+			conn = "reused connection"
+		}
+		fmt.Fprintf(w, "  %s %s %s\n", status, hop.Method, hop.URL)
+		fmt.Fprintf(w, "    %s\n", strings.Join([]string{
+			fmt.Sprintf("dns %.1fms", hop.DNSMs),
+			fmt.Sprintf("connect %.1fms", hop.ConnectMs),
+			fmt.Sprintf("tls %.1fms", hop.TLSMs),
+			fmt.Sprintf("ttfb %.1fms", hop.TTFBMs),
+			fmt.Sprintf("transfer %.1fms", hop.TransferMs),
+			fmt.Sprintf("total %.1fms", hop.TotalMs),
+			conn,
+		}, "  "))
+	}
+	if len(report.Hops) > 1 {
+		fmt.Fprintf(w, "  total %.1fms over %d hops\n", report.TotalMs, len(report.Hops))
+	}
+}
@@ -0,0 +1,162 @@
+package runtime
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/youmark/pkcs8"
+)
+
+// keyPassphraseEnv names the environment variable holding the passphrase for an
+// encrypted client key, so it never appears on the command line or in the plan.
+const keyPassphraseEnv = "REQ_KEY_PASSPHRASE"
+
+// buildTLSConfig returns the client TLS configuration for the plan: verification
+// settings, trusted CAs, server name, public key pins and client certificate.
+func buildTLSConfig(plan *planner.ExecutionPlan) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: plan.Insecure}
+	if plan.TLS == nil {
+		return config, nil
+	}
+
+	config.ServerName = plan.TLS.ServerName
+	if len(plan.TLS.Pins) > 0 {
+		config.VerifyConnection = verifyPins(plan.TLS.Pins)
+	}
+
+	if plan.TLS.CAPath != "" {
+		pool, err := loadCAPool(plan.TLS.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if plan.TLS.CertFile != "" {
+		cert, err := loadClientCert(plan.TLS.CertFile, plan.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// loadCAPool reads the PEM certificates in path, a bundle file or a directory of
+// them. Files in a directory that hold no certificates are skipped.
+func loadCAPool(path string) (*x509.CertPool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA directory: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.Type().IsRegular() || entry.Type()&os.ModeSymlink != 0 {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	pool := x509.NewCertPool()
+	found := false
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if pool.AppendCertsFromPEM(data) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// loadClientCert loads a PEM certificate chain and its private key. The key is read
+// from keyFile, or from certFile when keyFile is empty, and may be encrypted.
+func loadClientCert(certFile, keyFile string) (tls.Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+
+	keyPEM := certPEM
+	if keyFile != "" {
+		if keyPEM, err = os.ReadFile(keyFile); err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to read client key: %w", err)
+		}
+	} else {
+		keyFile = certFile
+	}
+
+	block, err := findPrivateKey(keyPEM, keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if block, err = decryptPrivateKey(block, keyFile); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(block))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate or key: %w", err)
+	}
+	return cert, nil
+}
+
+// findPrivateKey returns the first private key block in data.
+func findPrivateKey(data []byte, file string) (*pem.Block, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM private key found in %s", file)
+		}
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return block, nil
+		}
+	}
+}
+
+// decryptPrivateKey decrypts an encrypted PKCS#8 block with the passphrase from
+// keyPassphraseEnv. Legacy OpenSSL "Proc-Type: 4,ENCRYPTED" keys are rejected:
+// their encryption is insecure by design and Go no longer supports it. Other
+// blocks are returned unchanged.
+func decryptPrivateKey(block *pem.Block, file string) (*pem.Block, error) {
+	if _, legacy := block.Headers["DEK-Info"]; legacy {
+		return nil, fmt.Errorf("client key %s uses legacy PEM encryption, which is not supported; convert it to encrypted PKCS#8 with: openssl pkcs8 -topk8 -in %s -out client.key", file, file)
+	}
+	if block.Type != "ENCRYPTED PRIVATE KEY" {
+		return block, nil
+	}
+
+	passphrase, ok := os.LookupEnv(keyPassphraseEnv)
+	if !ok {
+		return nil, fmt.Errorf("client key %s is encrypted; set %s to its passphrase", file, keyPassphraseEnv)
+	}
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt client key %s: %w", file, err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt client key %s: %w", file, err)
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
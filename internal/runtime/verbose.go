@@ -0,0 +1,246 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// verboseBodyLimit is how much of a request body the verbose flag prints.
+const verboseBodyLimit = 2048
+
+// verboseTransport writes each request and response that passes through next to
+// stderr, so redirect hops and retries are shown as they happen. Credentials are
+// redacted unless showCredentials is set.
+type verboseTransport struct {
+	next            http.RoundTripper
+	showCredentials bool
+}
+
+func (t *verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.dumpRequest(req)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "* %v\n", err)
+		return nil, err
+	}
+	t.dumpResponse(resp)
+	return resp, nil
+}
+
+// dumpRequest writes the request line, headers and body preview of req.
+func (t *verboseTransport) dumpRequest(req *http.Request) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(os.Stderr, "> %s %s\n", req.Method, req.URL.RequestURI())
+	fmt.Fprintf(os.Stderr, "> Host: %s\n", host)
+	t.dumpHeaders("> ", req.Header)
+	fmt.Fprintf(os.Stderr, ">\n")
+	if preview := requestBodyPreview(req, !t.showCredentials); preview != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", preview)
+	}
+}
+
+// dumpResponse writes the status line and headers of resp.
+func (t *verboseTransport) dumpResponse(resp *http.Response) {
+	fmt.Fprintf(os.Stderr, "< %s %s\n", resp.Proto, resp.Status)
+	t.dumpHeaders("< ", resp.Header)
+	fmt.Fprintf(os.Stderr, "<\n")
+}
+
+// dumpHeaders writes headers sorted by name, one line per value.
+func (t *verboseTransport) dumpHeaders(prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if !t.showCredentials {
+				value = redactHeader(name, value)
+			}
+			fmt.Fprintf(os.Stderr, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+// requestBodyPreview returns the request body for display: text up to
+// verboseBodyLimit bytes, or a size note for binary, multipart and unreplayable bodies.
+// With redact set, credential fields of a form or JSON body are hidden.
+func requestBodyPreview(req *http.Request, redact bool) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	data, truncated, note := replayBody(req, verboseBodyLimit)
+	if note != "" {
+		return "[" + note + "]"
+	}
+	size := bodySize(req)
+
+	// A cut may split a multi-byte character, so trim back to a full rune before checking
+	text := data
+	for i := 0; truncated && i < utf8.UTFMax-1 && !utf8.Valid(text); i++ {
+		text = text[:len(text)-1]
+	}
+	if !utf8.Valid(text) || bytes.IndexByte(text, 0) >= 0 {
+		return fmt.Sprintf("[binary body, %s]", size)
+	}
+	if redact {
+		text = redactBody(req.Header.Get("Content-Type"), text)
+	}
+	if truncated {
+		return fmt.Sprintf("%s\n[truncated, %s]", text, size)
+	}
+	return string(text)
+}
+
+// replayBody reads up to limit bytes of the request body through GetBody, so the
+// body that is sent is untouched. Multipart bodies are streamed from their files
+// and bodies without GetBody cannot be read twice, so those return a note instead.
+func replayBody(req *http.Request, limit int) (data []byte, truncated bool, note string) {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		return nil, false, "multipart body, " + bodySize(req)
+	}
+	if req.GetBody == nil {
+		return nil, false, "streamed body, " + bodySize(req)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false, fmt.Sprintf("body unavailable: %v", err)
+	}
+	defer body.Close()
+	data, err = io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if err != nil {
+		return nil, false, fmt.Sprintf("body unavailable: %v", err)
+	}
+	if len(data) > limit {
+		return data[:limit], true, ""
+	}
+	return data, false, ""
+}
+
+// bodySize describes the request body's length for notes.
+func bodySize(req *http.Request) string {
+	if req.ContentLength >= 0 {
+		return fmt.Sprintf("%d bytes", req.ContentLength)
+	}
+	return "unknown size"
+}
+
+// credentialFields are the form, query and JSON fields whose values are hidden
+// when redacting, as sent to OAuth2 token endpoints, login forms and API key auth.
+var credentialFields = map[string]bool{
+	"password":      true,
+	"client_secret": true,
+	"refresh_token": true,
+	"access_token":  true,
+	"id_token":      true,
+	"api_key":       true,
+	"apikey":        true,
+}
+
+// redactBody hides the values of credential fields in a URL-encoded form or JSON
+// body. Other bodies, and JSON that doesn't parse, are returned unchanged.
+func redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return []byte(redactForm(string(body)))
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return redactJSON(body)
+	}
+	return body
+}
+
+// redactJSON hides the values of credential fields at any depth of a JSON
+// document. The document is only re-encoded when something was hidden.
+func redactJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if decoder.Decode(&doc) != nil || !redactJSONValue(doc) {
+		return body
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// redactJSONValue replaces credential fields in v in place, reporting whether any were found.
+func redactJSONValue(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if credentialFields[strings.ToLower(key)] {
+				v[key] = "***"
+				found = true
+			} else if redactJSONValue(value) {
+				found = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if redactJSONValue(value) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// redactForm hides the values of credential fields in a URL-encoded form body,
+// keeping the field order and names.
+func redactForm(body string) string {
+	pairs := strings.Split(body, "&")
+	for i, pair := range pairs {
+		name, _, ok := strings.Cut(pair, "=")
+		if key, err := url.QueryUnescape(name); ok && err == nil && credentialFields[key] {
+			pairs[i] = name + "=***"
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactHeader hides credential values in Authorization, Cookie and Set-Cookie
+// headers while keeping enough shape (scheme, cookie names, attributes) to debug with.
+func redactHeader(name, value string) string {
+	const redacted = "***"
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Proxy-Authorization":
+		if scheme, _, ok := strings.Cut(value, " "); ok {
+			return scheme + " " + redacted
+		}
+		return redacted
+	case "Cookie":
+		pairs := strings.Split(value, ";")
+		for i, pair := range pairs {
+			cookieName, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			pairs[i] = cookieName + "=" + redacted
+		}
+		return strings.Join(pairs, "; ")
+	case "Set-Cookie":
+		pair, attrs, hasAttrs := strings.Cut(value, ";")
+		cookieName, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if hasAttrs {
+			return cookieName + "=" + redacted + ";" + attrs
+		}
+		return cookieName + "=" + redacted
+	}
+	return value
+}
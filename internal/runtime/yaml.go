@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// plainYAML matches strings that YAML reads back as the same string when written
+// unquoted. Anything else (numbers, timestamps, indicators, ": ", " #") is quoted.
+var plainYAML = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_ ./@-]*$`)
+
+// yamlReserved are plain words YAML 1.1 or 1.2 resolve to booleans or null.
+var yamlReserved = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// writeYAML writes a decoded JSON value as a block-style YAML document. Object keys
+// keep the order of *orderedObject values.
+func writeYAML(w io.Writer, data interface{}) error {
+	var buf strings.Builder
+	appendYAML(&buf, data, 0)
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// appendYAML appends value as a block indented by indent spaces.
+func appendYAML(buf *strings.Builder, value interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := value.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		for _, key := range v.keys {
+			child := v.values[key]
+			buf.WriteString(pad + yamlString(key) + ":")
+			if isYAMLBlock(child) {
+				buf.WriteString("\n")
+				appendYAML(buf, child, indent+2)
+			} else {
+				buf.WriteString(" " + yamlScalar(child) + "\n")
+			}
+		}
+
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range v {
+			if !isYAMLBlock(item) {
+				buf.WriteString(pad + "- " + yamlScalar(item) + "\n")
+				continue
+			}
+			// Render the item one level deeper, then put the dash in its first line's indent
+			var child strings.Builder
+			appendYAML(&child, item, indent+2)
+			buf.WriteString(pad + "- " + child.String()[indent+2:])
+		}
+
+	default:
+		buf.WriteString(pad + yamlScalar(value) + "\n")
+	}
+}
+
+// isYAMLBlock reports whether value is a non-empty object or array, which is
+// written as an indented block rather than inline.
+func isYAMLBlock(value interface{}) bool {
+	switch v := value.(type) {
+	case *orderedObject:
+		return len(v.keys) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlScalar renders a scalar or empty collection inline.
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case *orderedObject:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// yamlString writes s plain when that is unambiguous and as a double-quoted
+// scalar otherwise. JSON string escapes are valid in YAML double quotes.
+func yamlString(s string) string {
+	if plainYAML.MatchString(s) && !strings.HasSuffix(s, " ") && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
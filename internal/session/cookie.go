@@ -0,0 +1,164 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cookie is a stored cookie with the attributes that decide where it is sent,
+// following the RFC 6265 storage model.
+type Cookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"` // empty matches any host the session is used for
+	Path     string    `json:"path,omitempty"`
+	HostOnly bool      `json:"host_only,omitempty"` // set without a Domain attribute, so sent to Domain only
+	Secure   bool      `json:"secure,omitempty"`
+	HTTPOnly bool      `json:"http_only,omitempty"`
+	Expires  time.Time `json:"expires,omitzero"` // zero keeps the cookie until the session is cleared
+}
+
+// Expired reports whether the cookie's expiry has passed at now.
+func (c Cookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// SetCookies stores the cookies from a response to u, replacing cookies with the
+// same name, domain and path. Cookies that are already expired, such as those with
+// Max-Age=0, delete their stored counterpart. Cookies for a domain u's host does not
+// belong to are ignored.
+func (s *Session) SetCookies(u *url.URL, setCookies []string, now time.Time) {
+	host := strings.ToLower(u.Hostname())
+	for _, header := range setCookies {
+		parsed, err := http.ParseSetCookie(header)
+		if err != nil {
+			continue
+		}
+
+		cookie := Cookie{
+			Name:     parsed.Name,
+			Value:    parsed.Value,
+			Domain:   host,
+			HostOnly: true,
+			Path:     parsed.Path,
+			Secure:   parsed.Secure,
+			HTTPOnly: parsed.HttpOnly,
+		}
+		if domain := strings.ToLower(strings.TrimPrefix(parsed.Domain, ".")); domain != "" {
+			if !domainMatch(host, domain) {
+				continue
+			}
+			cookie.Domain, cookie.HostOnly = domain, false
+		}
+		if !strings.HasPrefix(cookie.Path, "/") {
+			cookie.Path = defaultPath(u.Path)
+		}
+
+		// Max-Age wins over Expires; http.ParseSetCookie reports Max-Age<=0 as -1
+		switch {
+		case parsed.MaxAge < 0:
+			cookie.Expires = now
+		case parsed.MaxAge > 0:
+			cookie.Expires = now.Add(time.Duration(parsed.MaxAge) * time.Second)
+		case !parsed.Expires.IsZero():
+			cookie.Expires = parsed.Expires
+		}
+
+		s.storeCookie(cookie, now)
+	}
+}
+
+// storeCookie replaces any cookie with the same name, domain and path, dropping it
+// instead when the new cookie is already expired.
+func (s *Session) storeCookie(cookie Cookie, now time.Time) {
+	kept := s.Cookies[:0]
+	for _, existing := range s.Cookies {
+		if existing.Name == cookie.Name && existing.Domain == cookie.Domain && existing.Path == cookie.Path {
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	if !cookie.Expired(now) {
+		kept = append(kept, cookie)
+	}
+	s.Cookies = kept
+}
+
+// CookiesFor returns the stored cookies to send with a request to u: those whose
+// domain and path match, that have not expired, and, for Secure cookies, only over
+// HTTPS. Cookies with longer paths come first.
+func (s *Session) CookiesFor(u *url.URL, now time.Time) []*http.Cookie {
+	host := strings.ToLower(u.Hostname())
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var matched []Cookie
+	for _, cookie := range s.Cookies {
+		switch {
+		case cookie.Expired(now):
+		case cookie.Secure && u.Scheme != "https":
+		case cookie.HostOnly && cookie.Domain != host:
+		case !cookie.HostOnly && cookie.Domain != "" && !domainMatch(host, cookie.Domain):
+		case !pathMatch(path, cookie.Path):
+		default:
+			matched = append(matched, cookie)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return len(matched[i].Path) > len(matched[j].Path)
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matched))
+	for _, cookie := range matched {
+		cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	return cookies
+}
+
+// dropExpired removes cookies whose expiry has passed.
+func (s *Session) dropExpired(now time.Time) {
+	kept := s.Cookies[:0]
+	for _, cookie := range s.Cookies {
+		if !cookie.Expired(now) {
+			kept = append(kept, cookie)
+		}
+	}
+	s.Cookies = kept
+}
+
+// domainMatch reports whether host domain-matches domain (RFC 6265 section 5.1.3).
+// IP addresses only match themselves.
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch reports whether a request path path-matches a cookie path (RFC 6265
+// section 5.1.4). An empty cookie path matches every request.
+func pathMatch(path, cookiePath string) bool {
+	if cookiePath == "" || cookiePath == path {
+		return true
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// defaultPath is the cookie path used when Set-Cookie has none: the request path
+// up to its last slash (RFC 6265 section 5.1.4).
+func defaultPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
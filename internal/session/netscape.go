@@ -0,0 +1,162 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// netscapeHeader starts a Netscape cookies.txt file; curl and wget look for it.
+const netscapeHeader = "# Netscape HTTP Cookie File"
+
+// httpOnlyPrefix marks HttpOnly cookies in the domain field, as curl writes them.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// ReadCookieFile reads cookies from a Netscape cookies.txt file, as written by
+// curl -c, wget --save-cookies and browser export extensions. Expired cookies are
+// skipped.
+func ReadCookieFile(path string) ([]Cookie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookie file: %w", err)
+	}
+	defer f.Close()
+
+	cookies, err := ParseNetscapeCookies(f, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cookies, nil
+}
+
+// ParseNetscapeCookies parses Netscape cookies.txt lines: domain, include
+// subdomains, path, secure, expiry (Unix seconds, 0 for none), name and value,
+// separated by tabs. Comments and blank lines are ignored.
+func ParseNetscapeCookies(r io.Reader, now time.Time) ([]Cookie, error) {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, httpOnlyPrefix)
+		text = strings.TrimPrefix(text, httpOnlyPrefix)
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) == 6 {
+			// Some exporters drop the trailing tab of an empty value
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", line, fields[4])
+		}
+
+		domain := strings.ToLower(fields[0])
+		cookie := Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   strings.TrimPrefix(domain, "."),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(domain, "."),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HTTPOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0).UTC()
+		}
+		if cookie.Expired(now) {
+			continue
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookie file: %w", err)
+	}
+	return cookies, nil
+}
+
+// WriteCookieFile writes cookies to path in Netscape cookies.txt format with 0600
+// permissions, since the file holds credentials.
+func WriteCookieFile(path string, cookies []Cookie) error {
+	var b strings.Builder
+	if err := WriteNetscapeCookies(&b, cookies); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write cookie file: %w", err)
+	}
+	return nil
+}
+
+// WriteNetscapeCookies writes cookies as Netscape cookies.txt lines, headed by
+// the comment curl and wget expect. Cookies without a domain cannot be written
+// and are skipped.
+func WriteNetscapeCookies(w io.Writer, cookies []Cookie) error {
+	if _, err := fmt.Fprintf(w, "%s\n# Written by req; holds credentials, keep it private.\n\n", netscapeHeader); err != nil {
+		return err
+	}
+	for _, cookie := range cookies {
+		if cookie.Domain == "" {
+			continue
+		}
+		domain, subdomains := cookie.Domain, "FALSE"
+		if !cookie.HostOnly {
+			domain, subdomains = "."+domain, "TRUE"
+		}
+		if cookie.HTTPOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		path := cookie.Path
+		if path == "" {
+			path = "/"
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, subdomains, path, netscapeBool(cookie.Secure), expires, cookie.Name, cookie.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// netscapeBool formats a cookies.txt flag.
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// AddCookies stores cookies in the session, replacing any with the same name,
+// domain and path.
+func (s *Session) AddCookies(cookies []Cookie) {
+	now := time.Now()
+	for _, cookie := range cookies {
+		s.storeCookie(cookie, now)
+	}
+}
+
+// Matches reports whether the cookie would be sent to host (a host name without
+// a port), ignoring path, scheme and expiry.
+func (c Cookie) Matches(host string) bool {
+	host = strings.ToLower(host)
+	switch {
+	case c.Domain == "":
+		return true
+	case c.HostOnly:
+		return c.Domain == host
+	}
+	return domainMatch(host, c.Domain)
+}
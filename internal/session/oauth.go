@@ -0,0 +1,138 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RefreshMargin is how long before its expiry an access token is renewed, so a
+// token does not run out while a request is in flight.
+const RefreshMargin = 30 * time.Second
+
+// OAuth holds what is needed to renew a session's OAuth2 access token.
+type OAuth struct {
+	TokenEndpoint string    `json:"token_endpoint"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitzero"`  // absolute, from expires_in
+	GrantType     string    `json:"grant_type,omitempty"` // "client_credentials" when a new token can be requested without a refresh token
+	Scope         string    `json:"scope,omitempty"`
+	ClientID      string    `json:"client_id,omitempty"`
+	ClientSecret  string    `json:"client_secret,omitempty"`
+}
+
+// TokenResponse is an OAuth2 access token response (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // zero when the server did not say
+	Scope        string
+}
+
+// ParseTokenResponse extracts an access token response from a JSON body. It
+// reports false when the body has no access_token.
+func ParseTokenResponse(body []byte) (*TokenResponse, bool) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, false
+	}
+	accessToken, _ := data["access_token"].(string)
+	if accessToken == "" {
+		return nil, false
+	}
+
+	token := &TokenResponse{AccessToken: accessToken}
+	token.RefreshToken, _ = data["refresh_token"].(string)
+	token.Scope, _ = data["scope"].(string)
+	// expires_in is a number of seconds, though some servers send it as a string
+	var seconds float64
+	switch v := data["expires_in"].(type) {
+	case float64:
+		seconds = v
+	case string:
+		seconds, _ = strconv.ParseFloat(v, 64)
+	}
+	if seconds > 0 {
+		token.ExpiresIn = time.Duration(seconds * float64(time.Second))
+	}
+	return token, true
+}
+
+// SetToken stores a token obtained from the endpoint request. Tokens that can
+// expire or be refreshed keep the endpoint, so they can be renewed later. The
+// request's Basic credentials are not kept: they may be a user's login rather
+// than an OAuth2 client, so only a client_credentials grant records them (see
+// SetClientCredentials).
+func (s *Session) SetToken(token *TokenResponse, endpoint *http.Request, now time.Time) {
+	s.Authorization = "Bearer " + token.AccessToken
+	s.OAuth = nil
+	if endpoint == nil || (token.RefreshToken == "" && token.ExpiresIn == 0) {
+		return
+	}
+
+	s.OAuth = &OAuth{TokenEndpoint: endpoint.URL.String(), Scope: token.Scope}
+	s.applyRenewal(token, now)
+}
+
+// SetClientCredentials records that the session's token came from a
+// client_credentials grant, keeping the client credentials and scope so a new
+// token can be requested when it expires.
+func (s *Session) SetClientCredentials(clientID, clientSecret, scope string) {
+	if s.OAuth == nil {
+		return
+	}
+	s.OAuth.GrantType = "client_credentials"
+	s.OAuth.ClientID, s.OAuth.ClientSecret = clientID, clientSecret
+	if s.OAuth.Scope == "" {
+		s.OAuth.Scope = scope
+	}
+}
+
+// ApplyRefresh stores a token obtained by renewing the session's token. A
+// refresh token that was not rotated is kept.
+func (s *Session) ApplyRefresh(token *TokenResponse, now time.Time) {
+	s.Authorization = "Bearer " + token.AccessToken
+	s.applyRenewal(token, now)
+}
+
+func (s *Session) applyRenewal(token *TokenResponse, now time.Time) {
+	if token.RefreshToken != "" {
+		s.OAuth.RefreshToken = token.RefreshToken
+	}
+	s.OAuth.ExpiresAt = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.OAuth.ExpiresAt = now.Add(token.ExpiresIn).UTC()
+	}
+}
+
+// TokenExpiring reports whether the session's access token has expired or will
+// within RefreshMargin of now.
+func (s *Session) TokenExpiring(now time.Time) bool {
+	return s.OAuth != nil && !s.OAuth.ExpiresAt.IsZero() && !now.Add(RefreshMargin).Before(s.OAuth.ExpiresAt)
+}
+
+// RenewalForm returns the token request parameters that renew the access token:
+// a refresh_token grant when there is a refresh token, or a new client_credentials
+// grant. It reports false when the token cannot be renewed.
+func (o *OAuth) RenewalForm() (url.Values, bool) {
+	form := url.Values{}
+	switch {
+	case o.RefreshToken != "":
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", o.RefreshToken)
+	case o.GrantType == "client_credentials" && o.ClientID != "":
+		form.Set("grant_type", "client_credentials")
+		if o.Scope != "" {
+			form.Set("scope", o.Scope)
+		}
+	default:
+		return nil, false
+	}
+	// Public clients identify themselves in the body instead of with Basic auth
+	if o.ClientID != "" && o.ClientSecret == "" {
+		form.Set("client_id", o.ClientID)
+	}
+	return form, true
+}
@@ -4,18 +4,21 @@ package session
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Session represents a stored session for a host.
 type Session struct {
-	Host          string            `json:"host"`
-	Cookies       map[string]string `json:"cookies,omitempty"`
-	Authorization string            `json:"authorization,omitempty"` // Bearer token
+	Host          string   `json:"host"`
+	Cookies       []Cookie `json:"cookies,omitempty"`
+	Authorization string   `json:"authorization,omitempty"` // Bearer token
+	OAuth         *OAuth   `json:"oauth,omitempty"`         // set when the token expires or can be refreshed
 }
 
 var (
@@ -86,8 +89,20 @@ func LoadSession(host string) (*Session, error) {
 
 	var session Session
 	if err := json.Unmarshal(data, &session); err != nil {
-		return nil, fmt.Errorf("failed to parse session: %w", err)
+		// Sessions saved before cookies kept their attributes stored a name to value map
+		var legacy struct {
+			Session
+			Cookies map[string]string `json:"cookies"`
+		}
+		if json.Unmarshal(data, &legacy) != nil {
+			return nil, fmt.Errorf("failed to parse session: %w", err)
+		}
+		session = legacy.Session
+		for name, value := range legacy.Cookies {
+			session.Cookies = append(session.Cookies, Cookie{Name: name, Value: value, Path: "/"})
+		}
 	}
+	session.dropExpired(time.Now())
 
 	return &session, nil
 }
@@ -138,44 +153,42 @@ func ExtractHost(urlStr string) (string, error) {
 	return u.Host, nil
 }
 
-// UpdateSessionFromResponse updates a session from an HTTP response.
-// Captures Set-Cookie headers and access_token from JSON body.
-func UpdateSessionFromResponse(host string, setCookies []string, body []byte) (*Session, error) {
+// SocketHost returns the session key for requests sent over a Unix socket. Sessions
+// follow the socket rather than the URL's placeholder host, which sockets share.
+func SocketHost(socketPath string) string {
+	return "unix:" + filepath.Clean(socketPath)
+}
+
+// UpdateSessionFromResponse updates a session from the responses of an exchange,
+// redirect hops included. Captures Set-Cookie headers with their attributes and
+// access_token from the JSON body.
+func UpdateSessionFromResponse(host string, responses []*http.Response, body []byte) (*Session, error) {
 	session, err := LoadSession(host)
 	if err != nil {
 		return nil, err
 	}
 
 	if session == nil {
-		session = &Session{
-			Host:    host,
-			Cookies: make(map[string]string),
-		}
+		session = &Session{Host: host}
 	}
 
-	// Parse Set-Cookie headers
-	for _, cookieHeader := range setCookies {
-		// Simple cookie parsing (just get name=value part)
-		parts := strings.Split(cookieHeader, ";")
-		if len(parts) > 0 {
-			cookiePart := strings.TrimSpace(parts[0])
-			eqIdx := strings.Index(cookiePart, "=")
-			if eqIdx > 0 {
-				name := cookiePart[:eqIdx]
-				value := cookiePart[eqIdx+1:]
-				session.Cookies[name] = value
-			}
+	// Each response's cookies default their domain and path from its own URL
+	now := time.Now()
+	for _, resp := range responses {
+		if resp.Request == nil {
+			continue
 		}
+		session.SetCookies(resp.Request.URL, resp.Header.Values("Set-Cookie"), now)
 	}
 
-	// Try to extract access_token from JSON body
-	if len(body) > 0 {
-		var jsonData map[string]interface{}
-		if err := json.Unmarshal(body, &jsonData); err == nil {
-			if token, ok := jsonData["access_token"].(string); ok && token != "" {
-				session.Authorization = "Bearer " + token
-			}
+	// Try to extract an OAuth2 token from the JSON body; the final request was
+	// sent to the token endpoint
+	if token, ok := ParseTokenResponse(body); ok {
+		var endpoint *http.Request
+		if len(responses) > 0 {
+			endpoint = responses[len(responses)-1].Request
 		}
+		session.SetToken(token, endpoint, now)
 	}
 
 	return session, nil
@@ -210,13 +223,13 @@ func ListSessions() ([]string, error) {
 func RedactSession(session *Session) *Session {
 	redacted := &Session{
 		Host:          session.Host,
-		Cookies:       make(map[string]string),
 		Authorization: "",
 	}
 
-	// Redact cookies (show only names)
-	for name := range session.Cookies {
-		redacted.Cookies[name] = "***"
+	// Redact cookies (keep names and attributes)
+	for _, cookie := range session.Cookies {
+		cookie.Value = "***"
+		redacted.Cookies = append(redacted.Cookies, cookie)
 	}
 
 	// Redact authorization
@@ -224,6 +237,18 @@ func RedactSession(session *Session) *Session {
 		redacted.Authorization = "Bearer ***"
 	}
 
+	// Redact OAuth secrets (keep the endpoint, expiry and client ID)
+	if session.OAuth != nil {
+		oauth := *session.OAuth
+		if oauth.RefreshToken != "" {
+			oauth.RefreshToken = "***"
+		}
+		if oauth.ClientSecret != "" {
+			oauth.ClientSecret = "***"
+		}
+		redacted.OAuth = &oauth
+	}
+
 	return redacted
 }
 
@@ -0,0 +1,62 @@
+// Package styles holds the lipgloss styles shared by the TUI and terminal output.
+package styles
+
+import "github.com/charmbracelet/lipgloss"
+
+// Palette colors.
+var (
+	Accent = lipgloss.Color("62")
+	Muted  = lipgloss.Color("240")
+)
+
+var (
+	Title = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(Accent).
+		Padding(1, 2)
+
+	Error = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196")).
+		Padding(1, 2).
+		Width(80)
+
+	Success = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("46")).
+		Padding(1, 2).
+		Width(80)
+
+	Command = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("252")).
+		Padding(1, 2).
+		Width(80)
+
+	// JSON syntax highlighting styles
+	JSONKey = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("39")).
+		Bold(true)
+
+	JSONString = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46"))
+
+	JSONNumber = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("220"))
+
+	JSONBool = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("213"))
+
+	JSONNull = lipgloss.NewStyle().
+			Foreground(Muted).
+			Italic(true)
+
+	JSONPunct = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("252"))
+
+	Output = lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Accent)
+
+	// Table output styles
+	TableHeader = JSONKey
+	TableRule   = lipgloss.NewStyle().Foreground(Accent)
+)
@@ -8,58 +8,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/huh"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/adammpkins/req/internal/parser"
 	"github.com/adammpkins/req/internal/planner"
 	"github.com/adammpkins/req/internal/runtime"
-)
-
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("62")).
-			Padding(1, 2)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Padding(1, 2).
-			Width(80)
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("46")).
-			Padding(1, 2).
-			Width(80)
-
-	commandStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252")).
-			Padding(1, 2).
-			Width(80)
-
-	// JSON syntax highlighting styles
-	jsonKeyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("39")).
-			Bold(true)
-
-	jsonStringStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("46"))
-
-	jsonNumberStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("220"))
-
-	jsonBoolStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("213"))
-
-	jsonNullStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			Italic(true)
-
-	jsonPunctStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252"))
-
-	outputStyle = lipgloss.NewStyle().
-			Padding(1, 2).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62"))
+	"github.com/adammpkins/req/internal/tui/styles"
 )
 
 // View represents a TUI view interface.
@@ -280,16 +232,16 @@ func (b *BuilderView) executeCommand() tea.Cmd {
 func (b *BuilderView) View() string {
 	var s strings.Builder
 
-	s.WriteString(titleStyle.Render("req - Interactive Command Builder"))
+	s.WriteString(styles.Title.Render("req - Interactive Command Builder"))
 	s.WriteString("\n\n")
 
 	if b.err != nil {
-		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", b.err)))
+		s.WriteString(styles.Error.Render(fmt.Sprintf("Error: %v", b.err)))
 		s.WriteString("\n\n")
 	}
 
 	if b.response != "" {
-		s.WriteString(successStyle.Render(b.response))
+		s.WriteString(styles.Success.Render(b.response))
 		s.WriteString("\n\n")
 	}
 
@@ -316,7 +268,7 @@ func (b *BuilderView) View() string {
 		// The viewport handles its own height, so we just need to wrap it with the border style
 		viewportContent := b.viewport.View()
 		// Use the viewport's actual dimensions for the border
-		s.WriteString(outputStyle.Width(contentWidth + 4).Render(viewportContent))
+		s.WriteString(styles.Output.Width(contentWidth + 4).Render(viewportContent))
 		s.WriteString("\n\n")
 	}
 
@@ -333,7 +285,7 @@ func (b *BuilderView) View() string {
 				width = 80 // default width
 			}
 			wrapped := wrapText(cmdText, width)
-			s.WriteString(commandStyle.Render(wrapped))
+			s.WriteString(styles.Command.Render(wrapped))
 			s.WriteString("\n")
 			if b.response != "" {
 				s.WriteString("\n")
@@ -522,9 +474,9 @@ func highlightJSONLine(line string) string {
 					isKey := end < len(line) && line[end] == ':'
 					str := line[i:end]
 					if isKey {
-						result += jsonKeyStyle.Render(str)
+						result += styles.JSONKey.Render(str)
 					} else {
-						result += jsonStringStyle.Render(str)
+						result += styles.JSONString.Render(str)
 					}
 					i = end
 					break
@@ -535,7 +487,7 @@ func highlightJSONLine(line string) string {
 			}
 			if end >= len(line) {
 				// Unterminated string, just add it
-				result += jsonStringStyle.Render(line[i:])
+				result += styles.JSONString.Render(line[i:])
 				break
 			}
 			continue
@@ -550,23 +502,23 @@ func highlightJSONLine(line string) string {
 				line[i] == 'n' || line[i] == 'f') {
 				i++
 			}
-			result += jsonNumberStyle.Render(line[start:i])
+			result += styles.JSONNumber.Render(line[start:i])
 			continue
 		}
 		
 		// Handle boolean and null
 		if strings.HasPrefix(line[i:], "true") {
-			result += jsonBoolStyle.Render("true")
+			result += styles.JSONBool.Render("true")
 			i += 4
 			continue
 		}
 		if strings.HasPrefix(line[i:], "false") {
-			result += jsonBoolStyle.Render("false")
+			result += styles.JSONBool.Render("false")
 			i += 5
 			continue
 		}
 		if strings.HasPrefix(line[i:], "null") {
-			result += jsonNullStyle.Render("null")
+			result += styles.JSONNull.Render("null")
 			i += 4
 			continue
 		}
@@ -574,7 +526,7 @@ func highlightJSONLine(line string) string {
 		// Handle punctuation
 		if char == '{' || char == '}' || char == '[' || char == ']' || 
 		   char == ',' || char == ':' {
-			result += jsonPunctStyle.Render(string(char))
+			result += styles.JSONPunct.Render(string(char))
 			i++
 			continue
 		}
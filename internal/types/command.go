@@ -22,13 +22,16 @@ type Command struct {
 	Verb    Verb
 	Target  Target
 	Clauses []Clause
-	// For session verb, subcommand (show, clear, use)
+	// For session verb, subcommand (show, clear, use, import-cookies, export-cookies)
 	SessionSubcommand string
+	// For import-cookies and export-cookies, the Netscape cookies.txt file
+	SessionFile string
 }
 
 // Target represents the URL or resource being acted upon.
 type Target struct {
-	URL string
+	URL    string
+	Socket string // Unix socket path from a unix:// target, if any
 }
 
 // Clause represents a modifier clause in the command.
@@ -63,7 +66,7 @@ func (ParamsClause) clause() {}
 
 // AsClause represents an "as=" clause for output format.
 type AsClause struct {
-	Format string // json, csv, text, raw
+	Format string // json, yaml, ndjson, csv, table, text, raw
 }
 
 func (AsClause) clause() {}
@@ -84,7 +87,8 @@ func (UsingClause) clause() {}
 
 // RetryClause represents a "retry=" clause.
 type RetryClause struct {
-	Count int
+	Count              int
+	AllowNonIdempotent bool // retry=N:always also retries POST and PATCH
 }
 
 func (RetryClause) clause() {}
@@ -118,6 +122,13 @@ type PickClause struct {
 
 func (PickClause) clause() {}
 
+// ColumnsClause represents a "columns=" clause selecting and ordering tabular output.
+type ColumnsClause struct {
+	Columns []string // dot-separated keys, e.g. "id", "address.city"
+}
+
+func (ColumnsClause) clause() {}
+
 // EveryClause represents an "every=" clause for polling.
 type EveryClause struct {
 	Interval time.Duration
@@ -128,6 +139,7 @@ func (EveryClause) clause() {}
 // UntilClause represents an "until=" clause for conditional polling.
 type UntilClause struct {
 	Predicate string
+	Check     ExpectCheck // predicate parsed with expect= check syntax
 }
 
 func (UntilClause) clause() {}
@@ -140,11 +152,20 @@ type FieldClause struct {
 
 func (FieldClause) clause() {}
 
-// VerboseClause represents the "verbose" flag.
-type VerboseClause struct{}
+// VerboseClause represents the "verbose" flag, or "verbose=unredacted" to also show credentials.
+type VerboseClause struct {
+	ShowCredentials bool
+}
 
 func (VerboseClause) clause() {}
 
+// TimingClause represents the "timing" flag, or "timing=json" for a JSON breakdown.
+type TimingClause struct {
+	Format string // "text" or "json"
+}
+
+func (TimingClause) clause() {}
+
 // ResumeClause represents the "resume" flag for resumable downloads.
 type ResumeClause struct{}
 
@@ -192,9 +213,14 @@ func (ExpectClause) clause() {}
 type ExpectCheck struct {
 	Type  string // "status", "header", "contains", "jsonpath", "matches"
 	Name  string // for header checks, the header name
-	Value string // the value to check against
+	Value string // the value to check against (for jsonpath, the literal as written)
 	Path  string // for jsonpath, the JSONPath expression
 	Regex string // for matches, the regex pattern
+
+	// For jsonpath: the operator ("exists", "==", "!=", "<", "<=", ">", ">=", "=~")
+	// and the typed literal (string, float64, bool or nil; the pattern for =~)
+	Op       string
+	Expected interface{}
 }
 
 // FollowClause represents a "follow=" clause for redirect policy.
@@ -227,3 +253,106 @@ type InsecureClause struct {
 
 func (InsecureClause) clause() {}
 
+// HTTPClause represents an "http=" clause selecting the HTTP protocol version.
+type HTTPClause struct {
+	Version string // "1.1", "2", or "h2c"
+}
+
+func (HTTPClause) clause() {}
+
+// SocketClause represents a "socket=" clause naming a Unix socket to dial.
+type SocketClause struct {
+	Path string
+}
+
+func (SocketClause) clause() {}
+
+// CertClause represents a "cert=" clause naming a PEM client certificate.
+type CertClause struct {
+	Path string
+}
+
+func (CertClause) clause() {}
+
+// KeyClause represents a "key=" clause naming the client certificate's PEM private key.
+type KeyClause struct {
+	Path string
+}
+
+func (KeyClause) clause() {}
+
+// CAClause represents a "ca=" clause naming a PEM CA bundle file or a directory of them.
+type CAClause struct {
+	Path string
+}
+
+func (CAClause) clause() {}
+
+// ServerNameClause represents a "servername=" clause overriding the TLS server name (SNI).
+type ServerNameClause struct {
+	Name string
+}
+
+func (ServerNameClause) clause() {}
+
+// PinClause represents a "pin=" clause listing accepted server public key pins
+// ("sha256//" followed by a base64 SPKI hash).
+type PinClause struct {
+	Pins []string
+}
+
+func (PinClause) clause() {}
+
+// ResolveRule maps a host and port to the address to connect to instead.
+type ResolveRule struct {
+	Host    string // host name as it appears in the URL
+	Port    string
+	Address string // IP or host name to dial
+	ToPort  string // port to dial; empty keeps Port
+}
+
+// ResolveClause represents a "resolve=" clause overriding where connections go.
+type ResolveClause struct {
+	Rules []ResolveRule
+}
+
+func (ResolveClause) clause() {}
+
+// IPClause represents an "ip=" clause forcing IPv4 or IPv6.
+type IPClause struct {
+	Version string // "4" or "6"
+}
+
+func (IPClause) clause() {}
+
+// BindClause represents a "bind=" clause naming the local address to connect from.
+type BindClause struct {
+	Address string
+}
+
+func (BindClause) clause() {}
+
+// HARClause represents a "har=" clause recording the exchange to an HTTP Archive file.
+type HARClause struct {
+	Path   string
+	Redact bool // "har=path:redacted" hides credentials in headers and cookies
+}
+
+func (HARClause) clause() {}
+
+// CookiesClause represents a "cookies=@file" clause sending cookies from a
+// Netscape cookies.txt file with a single request.
+type CookiesClause struct {
+	Path string
+}
+
+func (CookiesClause) clause() {}
+
+// GrantClause represents a "grant=" clause requesting an OAuth2 token with
+// authenticate instead of sending a login body.
+type GrantClause struct {
+	Type  string // "client_credentials"
+	Scope string // space-separated scopes, optional
+}
+
+func (GrantClause) clause() {}
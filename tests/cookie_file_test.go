@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/runtime"
+	"github.com/adammpkins/req/internal/session"
+)
+
+const netscapeSample = "# Netscape HTTP Cookie File\n" +
+	"\n" +
+	".example.com\tTRUE\t/\tFALSE\t0\twide\t1\n" +
+	"#HttpOnly_api.example.com\tFALSE\t/v1\tTRUE\t4102444800\tsid\tabc\n" +
+	"old.example.com\tFALSE\t/\tFALSE\t1000\tstale\tx\n" +
+	"api.example.com\tFALSE\t/\tFALSE\t0\tempty\n"
+
+// TestNetscapeCookies covers reading and writing the Netscape cookies.txt format.
+func TestNetscapeCookies(t *testing.T) {
+	cookies, err := session.ParseNetscapeCookies(strings.NewReader(netscapeSample), time.Now())
+	if err != nil {
+		t.Fatalf("ParseNetscapeCookies() error = %v", err)
+	}
+	if len(cookies) != 3 {
+		t.Fatalf("cookies = %+v, want 3 without the expired one", cookies)
+	}
+	if c := cookies[0]; c.Domain != "example.com" || c.HostOnly || !c.Expires.IsZero() {
+		t.Errorf("wide = %+v", c)
+	}
+	if c := cookies[1]; c.Domain != "api.example.com" || !c.HostOnly || !c.HTTPOnly || !c.Secure || c.Path != "/v1" || c.Expires.Unix() != 4102444800 {
+		t.Errorf("sid = %+v", c)
+	}
+	if c := cookies[2]; c.Name != "empty" || c.Value != "" {
+		t.Errorf("empty = %+v", c)
+	}
+
+	var out bytes.Buffer
+	if err := session.WriteNetscapeCookies(&out, cookies); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Netscape HTTP Cookie File\n",
+		".example.com\tTRUE\t/\tFALSE\t0\twide\t1\n",
+		"#HttpOnly_api.example.com\tFALSE\t/v1\tTRUE\t4102444800\tsid\tabc\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	again, err := session.ParseNetscapeCookies(&out, time.Now())
+	if err != nil || len(again) != 3 || again[1] != cookies[1] {
+		t.Errorf("round trip = %+v, %v", again, err)
+	}
+
+	if _, err := session.ParseNetscapeCookies(strings.NewReader("example.com\tTRUE\t/\n"), time.Now()); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("malformed line error = %v", err)
+	}
+}
+
+// TestSessionCookieSubcommands covers parsing session import-cookies and export-cookies.
+func TestSessionCookieSubcommands(t *testing.T) {
+	cmd, err := parser.Parse("session import-cookies cookies.txt api.example.com:8443")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cmd.SessionSubcommand != "import-cookies" || cmd.SessionFile != "cookies.txt" || cmd.Target.URL != "https://api.example.com:8443" {
+		t.Errorf("cmd = %+v", cmd)
+	}
+
+	cmd, err = parser.Parse("session export-cookies /tmp/jar.txt")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cmd.SessionSubcommand != "export-cookies" || cmd.SessionFile != "/tmp/jar.txt" || cmd.Target.URL != "" {
+		t.Errorf("cmd = %+v", cmd)
+	}
+
+	if _, err := parser.Parse("session import-cookies"); err == nil {
+		t.Error("Parse() expected error without a file")
+	}
+	// Sessions are keyed by host and port, which cookies.txt can't supply
+	if _, err := parser.Parse("session import-cookies cookies.txt"); err == nil || !strings.Contains(err.Error(), "host") {
+		t.Errorf("Parse() error = %v, want the host required", err)
+	}
+}
+
+// TestImportCookiesIntoSession covers importing cookies.txt for a host with a
+// port and the next request to that host carrying the cookies.
+func TestImportCookiesIntoSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("cookie:" + r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+
+	host, _ := session.ExtractHost(server.URL)
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	binaryPath := filepath.Join(t.TempDir(), "req")
+	if err := exec.Command("go", "build", "-o", binaryPath, "../cmd/req").Run(); err != nil {
+		t.Skipf("Could not build binary: %v", err)
+	}
+	jar := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(jar, []byte("127.0.0.1\tFALSE\t/\tFALSE\t0\tsid\timported\nother.com\tFALSE\t/\tFALSE\t0\tforeign\t1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(binaryPath, "session", "import-cookies", jar, host).CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "Imported 1 cookie into session for "+host) {
+		t.Fatalf("import-cookies = %v\n%s", err, out)
+	}
+	stdout, _, err := runCommand(t, "read "+server.URL+"/me")
+	if err != nil || stdout != "cookie:sid=imported" {
+		t.Errorf("read = %q, %v", stdout, err)
+	}
+}
+
+// TestCookiesClause covers cookies=@file sending matching cookies for one request
+// without applying or changing the stored session.
+func TestCookiesClause(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("cookie:" + r.Header.Get("Cookie") + " auth:" + r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	host, _ := session.ExtractHost(server.URL)
+	stored := &session.Session{Host: host, Authorization: "Bearer stored", Cookies: []session.Cookie{{Name: "stored", Value: "1"}}}
+	if err := session.SaveSession(stored); err != nil {
+		t.Fatal(err)
+	}
+	defer session.DeleteSession(host)
+
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	jar := "127.0.0.1\tFALSE\t/\tFALSE\t0\tsid\tfrom-file\n" +
+		"127.0.0.1\tFALSE\t/admin\tFALSE\t0\tadmin\tx\n" +
+		"127.0.0.1\tFALSE\t/\tTRUE\t0\tsecure\tx\n" +
+		"example.com\tFALSE\t/\tFALSE\t0\tforeign\tx\n"
+	if err := os.WriteFile(path, []byte(jar), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runCommand(t, "read "+server.URL+"/users cookies=@"+path+" include='cookie: extra=2'")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if stdout != "cookie:extra=2; sid=from-file auth:" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if strings.Contains(stderr, "Using session") {
+		t.Errorf("stored session applied alongside cookies=:\n%s", stderr)
+	}
+	if sess, _ := session.LoadSession(host); sess == nil || len(sess.Cookies) != 1 || sess.Cookies[0].Name != "stored" {
+		t.Errorf("stored session changed: %+v", sess)
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, _, err := runCommand(t, "read "+server.URL+"/ cookies=@"+filepath.Join(t.TempDir(), "none.txt"))
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 5 {
+			t.Errorf("error = %v, want exit code 5", err)
+		}
+	})
+
+	t.Run("requires @file", func(t *testing.T) {
+		if _, err := parser.Parse("read https://example.com cookies=sid=1"); err == nil {
+			t.Error("Parse() expected error for cookies= without @")
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/session"
+)
+
+// TestSessionCookieJar covers storing cookies with their attributes on
+// authenticate and sending them only where domain, path, scheme and expiry match.
+func TestSessionCookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/start":
+			// No Path attribute: defaults to /auth
+			w.Header().Add("Set-Cookie", "flow=started")
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/login":
+			w.Header().Add("Set-Cookie", "sid=abc; Path=/; HttpOnly")
+			w.Header().Add("Set-Cookie", "admin=root; Path=/admin")
+			w.Header().Add("Set-Cookie", "tls=only; Path=/; Secure")
+			w.Header().Add("Set-Cookie", "short=lived; Path=/; Max-Age=3600")
+			w.Header().Add("Set-Cookie", "gone=x; Path=/; Max-Age=0")
+			w.Header().Add("Set-Cookie", "stale=x; Path=/; Expires=Thu, 01 Jan 2015 00:00:00 GMT")
+			w.Header().Add("Set-Cookie", "other=x; Path=/; Domain=example.com")
+			w.Write([]byte("ok"))
+		default:
+			w.Write([]byte("cookies:" + r.Header.Get("Cookie")))
+		}
+	}))
+	defer server.Close()
+
+	host, _ := session.ExtractHost(server.URL)
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	if _, stderr, err := runCommand(t, "authenticate "+server.URL+"/auth/start"); err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+
+	t.Run("attributes stored", func(t *testing.T) {
+		sess, err := session.LoadSession(host)
+		if err != nil || sess == nil {
+			t.Fatalf("LoadSession() = %v, %v", sess, err)
+		}
+		stored := map[string]session.Cookie{}
+		for _, cookie := range sess.Cookies {
+			stored[cookie.Name] = cookie
+		}
+		for _, name := range []string{"gone", "stale", "other"} {
+			if _, ok := stored[name]; ok {
+				t.Errorf("cookie %s should not be stored", name)
+			}
+		}
+		if c := stored["flow"]; c.Path != "/auth" || !c.HostOnly || c.Domain != "127.0.0.1" {
+			t.Errorf("flow = %+v, want host-only with default path /auth", c)
+		}
+		if c := stored["sid"]; !c.HTTPOnly || c.Path != "/" || !c.Expires.IsZero() {
+			t.Errorf("sid = %+v", c)
+		}
+		if c := stored["tls"]; !c.Secure {
+			t.Errorf("tls = %+v, want Secure", c)
+		}
+		if c := stored["short"]; c.Expires.Before(time.Now().Add(59*time.Minute)) || c.Expires.After(time.Now().Add(time.Hour)) {
+			t.Errorf("short expires %v, want about an hour from now", c.Expires)
+		}
+	})
+
+	t.Run("path and scheme matching", func(t *testing.T) {
+		stdout, _, err := runCommand(t, "read "+server.URL+"/api/users")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stdout, "sid=abc") || !strings.Contains(stdout, "short=lived") {
+			t.Errorf("root cookies not sent: %s", stdout)
+		}
+		for _, name := range []string{"admin=", "tls=", "flow="} {
+			if strings.Contains(stdout, name) {
+				t.Errorf("%s sent to /api/users: %s", name, stdout)
+			}
+		}
+
+		stdout, _, _ = runCommand(t, "read "+server.URL+"/admin/panel")
+		if !strings.HasPrefix(stdout, "cookies:admin=root; ") {
+			t.Errorf("admin cookie not sent first on /admin/panel: %s", stdout)
+		}
+		stdout, _, _ = runCommand(t, "read "+server.URL+"/administrator")
+		if strings.Contains(stdout, "admin=root") {
+			t.Errorf("/admin cookie sent to /administrator: %s", stdout)
+		}
+	})
+
+	t.Run("expired cookies dropped on load", func(t *testing.T) {
+		err := session.SaveSession(&session.Session{Host: host, Cookies: []session.Cookie{
+			{Name: "live", Value: "1", Path: "/"},
+			{Name: "dead", Value: "1", Path: "/", Expires: time.Now().Add(-time.Minute)},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sess, err := session.LoadSession(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sess.Cookies) != 1 || sess.Cookies[0].Name != "live" {
+			t.Errorf("cookies = %+v, want only live", sess.Cookies)
+		}
+	})
+
+	t.Run("legacy session file", func(t *testing.T) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			t.Skip("no home directory")
+		}
+		path := filepath.Join(home, ".config", "req", "session_"+strings.ReplaceAll(host, ":", "_")+".json")
+		if err := os.WriteFile(path, []byte(`{"host":"`+host+`","cookies":{"sid":"legacy"}}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		stdout, _, err := runCommand(t, "read "+server.URL+"/anything")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if stdout != "cookies:sid=legacy" {
+			t.Errorf("stdout = %q", stdout)
+		}
+	})
+}
+
+// TestSessionCookieDomains covers Domain attribute handling without a network.
+func TestSessionCookieDomains(t *testing.T) {
+	now := time.Now()
+	sess := &session.Session{Host: "www.example.com"}
+	from, _ := url.Parse("https://www.example.com/login")
+	sess.SetCookies(from, []string{
+		"wide=1; Domain=.example.com; Path=/",
+		"narrow=1; Path=/",
+		"foreign=1; Domain=other.com",
+		"sibling=1; Domain=api.example.com",
+	}, now)
+
+	names := func(target string) string {
+		u, _ := url.Parse(target)
+		var got []string
+		for _, cookie := range sess.CookiesFor(u, now) {
+			got = append(got, cookie.Name)
+		}
+		return strings.Join(got, ",")
+	}
+	for target, want := range map[string]string{
+		"https://www.example.com/":      "wide,narrow",
+		"https://api.example.com/":      "wide",
+		"https://example.com/":          "wide",
+		"https://notexample.com/":       "",
+		"https://other.com/":            "",
+		"https://deep.www.example.com/": "wide",
+	} {
+		if got := names(target); got != want {
+			t.Errorf("cookies for %s = %q, want %q", target, got, want)
+		}
+	}
+
+	// A later Set-Cookie with the same name, domain and path replaces the cookie
+	sess.SetCookies(from, []string{"narrow=2; Path=/"}, now)
+	sess.SetCookies(from, []string{"wide=; Domain=example.com; Path=/; Max-Age=0"}, now)
+	u, _ := url.Parse("https://www.example.com/")
+	if cookies := sess.CookiesFor(u, now); len(cookies) != 1 || cookies[0].Value != "2" {
+		t.Errorf("cookies after update = %v", cookies)
+	}
+}
+
+// TestAuthenticateRedirectToOtherHost covers authenticate following a 307 to
+// another host: the body is replayed but credentials stay behind.
+func TestAuthenticateRedirectToOtherHost(t *testing.T) {
+	var method, body, authorization, cookie string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(data)
+		authorization, cookie = r.Header.Get("Authorization"), r.Header.Get("Cookie")
+		w.Write([]byte(`{"token":"landed"}`))
+	}))
+	defer other.Close()
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "hop=1; Path=/")
+		http.Redirect(w, r, otherURL+"/land", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	host, _ := session.ExtractHost(server.URL)
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+	if err := session.SaveSession(&session.Session{Host: host, Cookies: []session.Cookie{{Name: "sid", Value: "secret", Path: "/"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := runCommand(t, "authenticate "+server.URL+`/login with='{"user":"ada"}' include='header: Authorization: Bearer secret'`)
+	if err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+	if method != "POST" || body != `{"user":"ada"}` {
+		t.Errorf("redirected request = %s %q, want the POST body replayed", method, body)
+	}
+	if authorization != "" || cookie != "" {
+		t.Errorf("credentials sent to another host: Authorization %q, Cookie %q", authorization, cookie)
+	}
+	u, _ := url.Parse(server.URL + "/")
+	if sess, _ := session.LoadSession(host); sess == nil || len(sess.CookiesFor(u, time.Now())) != 2 {
+		t.Errorf("session = %+v, want the hop cookie stored with sid", sess)
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// TestCSVOutput tests as=csv conversion of JSON arrays and normalization of CSV responses.
+func TestCSVOutput(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"users": [
+			{"id": 1, "name": "Ada, Countess", "address": {"city": "London", "zip": null}, "tags": ["math"]},
+			{"id": 2, "name": "Grace \"Amazing\"", "active": true, "address": {"city": "NYC"}}
+		]}`))
+	})
+	ts.mux.HandleFunc("/users.csv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Write([]byte("\xef\xbb\xbfid,name,note\n1,Ada,\"multi\nline\"\n2,Grace,\n"))
+	})
+	ts.mux.HandleFunc("/broken.csv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("id,name\n1,Ada,extra\n"))
+	})
+
+	tests := []struct {
+		name   string
+		cmdStr string
+		want   string
+	}{
+		{
+			name:   "flattened union of keys",
+			cmdStr: "read " + ts.URL() + "/users pick=$.users as=csv",
+			want: "id,name,address.city,address.zip,tags,active\r\n" +
+				"1,\"Ada, Countess\",London,,\"[\"\"math\"\"]\",\r\n" +
+				"2,\"Grace \"\"Amazing\"\"\",NYC,,,true\r\n",
+		},
+		{
+			name:   "selected columns",
+			cmdStr: "read " + ts.URL() + "/users pick=$.users as=csv columns=name,address.city,id",
+			want:   "name,address.city,id\r\n\"Ada, Countess\",London,1\r\n\"Grace \"\"Amazing\"\"\",NYC,2\r\n",
+		},
+		{
+			name:   "nested object as a column",
+			cmdStr: "read " + ts.URL() + "/users pick=$.users[0] as=csv columns=id,address",
+			want:   "id,address\r\n1,\"{\"\"city\"\":\"\"London\"\",\"\"zip\"\":null}\"\r\n",
+		},
+		{
+			name:   "csv response normalized",
+			cmdStr: "read " + ts.URL() + "/users.csv as=csv",
+			want:   "id,name,note\r\n1,Ada,\"multi\r\nline\"\r\n2,Grace,\r\n",
+		},
+		{
+			name:   "csv response columns",
+			cmdStr: "read " + ts.URL() + "/users.csv as=csv columns=name,id",
+			want:   "name,id\r\nAda,1\r\nGrace,2\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, tt.cmdStr)
+			if err != nil {
+				t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+
+	_, _, err := runCommand(t, "read "+ts.URL()+"/broken.csv as=csv")
+	if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 9 || !strings.Contains(execErr.Message, "not valid CSV") {
+		t.Errorf("expected invalid CSV error, got %v", err)
+	}
+}
+
+// TestCSVOutputKeyOrder tests that columns follow the response's field order.
+func TestCSVOutputKeyOrder(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"sku": "a1", "qty": 2}, {"sku": "b2", "price": 9.5, "qty": 1}, 7]`))
+	})
+
+	stdout, stderr, err := runCommand(t, "read "+ts.URL()+"/items as=csv")
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	want := "sku,qty,price,value\r\na1,2,,\r\nb2,1,9.5,\r\n,,,7\r\n"
+	if stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+
+	_, stderr, err = runCommand(t, "read "+ts.URL()+"/items as=csv columns=sku,colour")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(stderr, `column "colour" not found`) {
+		t.Errorf("expected a warning for an unknown column, stderr: %s", stderr)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// TestContentDecoding tests every supported Content-Encoding, stacked codings,
+// and the warning for unsupported ones.
+func TestContentDecoding(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	const payload = `{"message": "hello, compressed world"}`
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"br":   func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			enc, _ := zstd.NewWriter(w)
+			return enc
+		},
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw-deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+	encode := func(name string, data []byte) []byte {
+		var buf bytes.Buffer
+		w := encoders[name](&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	var acceptEncoding string
+	ts.mux.HandleFunc("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		// Codings are applied in the order listed
+		body := []byte(payload)
+		var header []string
+		for _, name := range strings.Split(r.URL.Query().Get("codings"), ",") {
+			body = encode(name, body)
+			header = append(header, strings.TrimPrefix(name, "raw-"))
+		}
+		w.Header().Set("Content-Encoding", strings.Join(header, ", "))
+		w.Write(body)
+	})
+	ts.mux.HandleFunc("/unknown", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "compress")
+		w.Write([]byte("\x1f\x9d\x90encoded"))
+	})
+
+	for _, codings := range []string{"gzip", "br", "zstd", "deflate", "raw-deflate", "deflate,gzip", "zstd,br"} {
+		t.Run(codings, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, "read "+ts.URL()+"/encoded?codings="+codings)
+			if err != nil {
+				t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+			}
+			if stdout != payload {
+				t.Errorf("stdout = %q, want %q", stdout, payload)
+			}
+			if !strings.Contains(stderr, "Decompressed") {
+				t.Errorf("expected decompression note, stderr: %s", stderr)
+			}
+		})
+	}
+
+	if acceptEncoding != "gzip, br, zstd, deflate" {
+		t.Errorf("Accept-Encoding = %q, want gzip, br, zstd, deflate", acceptEncoding)
+	}
+
+	stdout, stderr, err := runCommand(t, "read "+ts.URL()+"/unknown")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(stderr, `unsupported Content-Encoding "compress"`) {
+		t.Errorf("expected a warning for an unsupported encoding, stderr: %s", stderr)
+	}
+	if stdout != "\x1f\x9d\x90encoded" {
+		t.Errorf("expected the encoded body to be passed through to a pipe, got %q", stdout)
+	}
+
+	// HEAD responses carry Content-Encoding without a body
+	if _, stderr, err := runCommand(t, "inspect "+ts.URL()+"/encoded?codings=gzip"); err != nil {
+		t.Errorf("inspect error = %v\nstderr: %s", err, stderr)
+	}
+}
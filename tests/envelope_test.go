@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// envelope mirrors the document written by as=envelope.
+type envelope struct {
+	Status    int                 `json:"status"`
+	URL       string              `json:"url"`
+	Redirects []map[string]any    `json:"redirects"`
+	Headers   map[string][]string `json:"headers"`
+	Timing    *struct {
+		Hops    []map[string]any `json:"hops"`
+		TotalMs float64          `json:"total_ms"`
+	} `json:"timing"`
+	Attempts int `json:"attempts"`
+	Retries  int `json:"retries"`
+	Expect   []struct {
+		Check   string `json:"check"`
+		Passed  bool   `json:"passed"`
+		Message string `json:"message"`
+	} `json:"expect"`
+	Body         any    `json:"body"`
+	BodyEncoding string `json:"body_encoding"`
+	Size         int    `json:"size"`
+	Error        string `json:"error"`
+	ExitCode     int    `json:"exit_code"`
+}
+
+// TestResultEnvelope covers as=envelope for redirects, retries, expect results,
+// body encodings and failures, with exit codes unchanged.
+func TestResultEnvelope(t *testing.T) {
+	var flaky int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/users", http.StatusMovedPermanently)
+		case "/users":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Link", `</users?page=2>; rel="next"`)
+			w.Header().Add("Link", `</users?page=9>; rel="last"`)
+			w.Write([]byte(`{"users":[{"name":"b","id":2},{"name":"a","id":1}]}`))
+		case "/flaky":
+			flaky++
+			if flaky < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("recovered"))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0xff, 0x00, 0xfe})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	run := func(t *testing.T, command string) (envelope, error) {
+		t.Helper()
+		stdout, _, err := runCommand(t, command)
+		var env envelope
+		if jsonErr := json.Unmarshal([]byte(stdout), &env); jsonErr != nil {
+			t.Fatalf("stdout is not a JSON envelope: %v\n%s", jsonErr, stdout)
+		}
+		return env, err
+	}
+
+	t.Run("redirects and headers", func(t *testing.T) {
+		env, err := run(t, "read "+server.URL+"/old as=envelope")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if env.Status != 200 || env.URL != server.URL+"/users" || env.ExitCode != 0 {
+			t.Errorf("status %d, url %s, exit %d", env.Status, env.URL, env.ExitCode)
+		}
+		if len(env.Redirects) != 1 || env.Redirects[0]["status"] != float64(301) || env.Redirects[0]["url"] != server.URL+"/old" {
+			t.Errorf("redirects = %v", env.Redirects)
+		}
+		if len(env.Headers["Link"]) != 2 {
+			t.Errorf("Link headers = %v, want both values", env.Headers["Link"])
+		}
+		if env.Timing == nil || len(env.Timing.Hops) != 2 {
+			t.Errorf("timing = %+v, want two hops", env.Timing)
+		}
+		if env.BodyEncoding != "json" || env.Size != 51 {
+			t.Errorf("body encoding %s, size %d", env.BodyEncoding, env.Size)
+		}
+		users := env.Body.(map[string]any)["users"].([]any)
+		if len(users) != 2 || users[0].(map[string]any)["id"] != float64(2) {
+			t.Errorf("body = %v", env.Body)
+		}
+	})
+
+	t.Run("key order is kept", func(t *testing.T) {
+		stdout, _, _ := runCommand(t, "read "+server.URL+"/users as=envelope")
+		if strings.Index(stdout, `"name": "b"`) > strings.Index(stdout, `"id": 2`) {
+			t.Errorf("body keys reordered:\n%s", stdout)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		env, err := run(t, "read "+server.URL+"/flaky retry=3 backoff=1ms..2ms as=envelope")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if env.Attempts != 3 || env.Retries != 2 || env.Body != "recovered" || env.BodyEncoding != "text" {
+			t.Errorf("attempts %d, retries %d, body %v (%s)", env.Attempts, env.Retries, env.Body, env.BodyEncoding)
+		}
+	})
+
+	t.Run("expect results", func(t *testing.T) {
+		env, err := run(t, "read "+server.URL+"/users expect=status:200,jsonpath:$.users.length==3,contains:nobody as=envelope")
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 3 {
+			t.Fatalf("error = %v, want exit code 3", err)
+		}
+		if env.ExitCode != 3 || len(env.Expect) != 3 {
+			t.Fatalf("exit %d, expect = %+v", env.ExitCode, env.Expect)
+		}
+		if !env.Expect[0].Passed || env.Expect[0].Check != "status:200" {
+			t.Errorf("status check = %+v", env.Expect[0])
+		}
+		if env.Expect[1].Passed || env.Expect[1].Check != "jsonpath:$.users.length==3" || env.Expect[1].Message == "" {
+			t.Errorf("jsonpath check = %+v", env.Expect[1])
+		}
+		if env.Expect[2].Passed {
+			t.Errorf("contains check = %+v", env.Expect[2])
+		}
+	})
+
+	t.Run("binary body", func(t *testing.T) {
+		env, err := run(t, "read "+server.URL+"/binary as=envelope")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if env.BodyEncoding != "base64" || env.Body != "/wD+" {
+			t.Errorf("body %v (%s), want base64", env.Body, env.BodyEncoding)
+		}
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		env, err := run(t, "read "+server.URL+"/missing as=envelope")
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 4 {
+			t.Fatalf("error = %v, want exit code 4", err)
+		}
+		if env.Status != 404 || env.ExitCode != 4 {
+			t.Errorf("status %d, exit %d", env.Status, env.ExitCode)
+		}
+	})
+
+	t.Run("connection failure", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		env, err := run(t, "read "+closed.URL+"/ as=envelope")
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 4 {
+			t.Fatalf("error = %v, want exit code 4", err)
+		}
+		if env.Error == "" || env.ExitCode != 4 || env.Status != 0 || env.Body != nil {
+			t.Errorf("envelope = %+v", env)
+		}
+	})
+
+	t.Run("invalid combinations", func(t *testing.T) {
+		for _, command := range []string{
+			"save " + server.URL + "/users as=envelope",
+			"read " + server.URL + "/users as=envelope to=out.json",
+			"read " + server.URL + "/users as=envelope pick=$.users",
+			"watch " + server.URL + "/users as=envelope",
+		} {
+			cmd, err := parser.Parse(command)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", command, err)
+			}
+			if _, err := planner.Plan(cmd); err == nil {
+				t.Errorf("Plan(%q) expected error", command)
+			}
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// harFile mirrors the parts of a HAR 1.2 document the tests check.
+type harFile struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []struct {
+			Request struct {
+				Method      string              `json:"method"`
+				URL         string              `json:"url"`
+				Headers     []map[string]string `json:"headers"`
+				Cookies     []map[string]any    `json:"cookies"`
+				QueryString []map[string]string `json:"queryString"`
+				PostData    *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status      int                 `json:"status"`
+				Headers     []map[string]string `json:"headers"`
+				Cookies     []map[string]any    `json:"cookies"`
+				RedirectURL string              `json:"redirectURL"`
+				Content     struct {
+					Size     int    `json:"size"`
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+			Timings map[string]float64 `json:"timings"`
+			Error   string             `json:"_error"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// TestHARExport covers har= across redirects, retries, bodies, cookies and
+// :redacted, checking the file is a HAR 1.2 document.
+func TestHARExport(t *testing.T) {
+	var flaky int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "cookie-secret", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/final?step=2", http.StatusFound)
+		case "/final":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		case "/flaky":
+			flaky++
+			if flaky < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("recovered"))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0xff, 0x00, 0xfe})
+		}
+	}))
+	defer server.Close()
+
+	defer func(version string) { runtime.Version = version }(runtime.Version)
+	runtime.Version = "v1.2.3"
+
+	record := func(t *testing.T, command string) harFile {
+		t.Helper()
+		// An existing, world-readable file is replaced with a private one
+		path := filepath.Join(t.TempDir(), "trace.har")
+		if err := os.WriteFile(path, []byte("stale"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, stderr, err := runCommand(t, command+" har="+path)
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if !strings.Contains(stderr, "HAR written to "+path) {
+			t.Errorf("stderr missing HAR note:\n%s", stderr)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var har harFile
+		if err := json.Unmarshal(data, &har); err != nil {
+			t.Fatalf("not a HAR document: %v\n%s", err, data)
+		}
+		if har.Log.Version != "1.2" || har.Log.Creator.Name != "req" || har.Log.Creator.Version != "v1.2.3" {
+			t.Errorf("log version %q, creator %+v", har.Log.Version, har.Log.Creator)
+		}
+		if info, err := os.Stat(path); err != nil {
+			t.Error(err)
+		} else if info.Mode().Perm() != 0600 {
+			t.Errorf("HAR file mode = %v, want 0600", info.Mode().Perm())
+		}
+		return har
+	}
+
+	t.Run("redirect hops", func(t *testing.T) {
+		har := record(t, "read "+server.URL+"/start include='header: Authorization: Bearer token-secret'")
+		entries := har.Log.Entries
+		if len(entries) != 2 {
+			t.Fatalf("entries = %d, want 2", len(entries))
+		}
+		first, second := entries[0], entries[1]
+		if first.Response.Status != 302 || first.Response.RedirectURL != "/final?step=2" {
+			t.Errorf("first entry status %d, redirectURL %q", first.Response.Status, first.Response.RedirectURL)
+		}
+		if len(first.Response.Cookies) != 1 || first.Response.Cookies[0]["value"] != "cookie-secret" || first.Response.Cookies[0]["httpOnly"] != true {
+			t.Errorf("response cookies = %v", first.Response.Cookies)
+		}
+		if second.Request.URL != server.URL+"/final?step=2" || len(second.Request.QueryString) != 1 || second.Request.QueryString[0]["value"] != "2" {
+			t.Errorf("second request url %s, query %v", second.Request.URL, second.Request.QueryString)
+		}
+		if len(second.Request.Cookies) != 1 || second.Request.Cookies[0]["name"] != "sid" {
+			t.Errorf("request cookies = %v", second.Request.Cookies)
+		}
+		if !hasHeader(second.Request.Headers, "Authorization", "Bearer token-secret") {
+			t.Errorf("request headers = %v", second.Request.Headers)
+		}
+		if second.Response.Status != 200 || second.Response.Content.Text != `{"ok":true}` || second.Response.Content.Size != 11 {
+			t.Errorf("final response = %+v", second.Response)
+		}
+		for _, phase := range []string{"blocked", "dns", "connect", "send", "wait", "receive", "ssl"} {
+			if _, ok := first.Timings[phase]; !ok {
+				t.Errorf("timings missing %s: %v", phase, first.Timings)
+			}
+		}
+		if first.Timings["ssl"] != -1 || first.Timings["wait"] < 0 {
+			t.Errorf("timings = %v", first.Timings)
+		}
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "trace.har")
+		if _, _, err := runCommand(t, "read "+server.URL+"/start include='header: Authorization: Bearer token-secret' har="+path+":redacted"); err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"token-secret", "cookie-secret"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("HAR leaks %q:\n%s", secret, data)
+			}
+		}
+		if !strings.Contains(string(data), "Bearer ***") {
+			t.Errorf("Authorization not redacted:\n%s", data)
+		}
+
+		// Credential query parameters and body fields are hidden, other values kept
+		for _, body := range []string{
+			`with='{"user":"ada","password":"body-secret"}'`,
+			`with='user=ada&client_secret=body-secret' include='header: Content-Type: application/x-www-form-urlencoded'`,
+		} {
+			if _, _, err := runCommand(t, "send "+server.URL+"/final?access_token=query-secret&page=2 "+body+" har="+path+":redacted"); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{"query-secret", "body-secret"} {
+				if strings.Contains(string(data), secret) {
+					t.Errorf("HAR leaks %q:\n%s", secret, data)
+				}
+			}
+			var har harFile
+			if err := json.Unmarshal(data, &har); err != nil {
+				t.Fatal(err)
+			}
+			request := har.Log.Entries[0].Request
+			if !strings.HasSuffix(request.URL, "?access_token=***&page=2") || request.PostData == nil || !strings.Contains(request.PostData.Text, "ada") {
+				t.Errorf("request = %s %+v", request.URL, request.PostData)
+			}
+		}
+	})
+
+	t.Run("request body", func(t *testing.T) {
+		har := record(t, "send "+server.URL+`/final with='{"name":"req"}'`)
+		postData := har.Log.Entries[0].Request.PostData
+		if postData == nil || postData.Text != `{"name":"req"}` || postData.MimeType != "application/json" {
+			t.Errorf("postData = %+v", postData)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		har := record(t, "read "+server.URL+"/flaky retry=2 backoff=1ms..2ms")
+		if len(har.Log.Entries) != 2 || har.Log.Entries[0].Response.Status != 503 || har.Log.Entries[1].Response.Content.Text != "recovered" {
+			t.Errorf("entries = %+v", har.Log.Entries)
+		}
+	})
+
+	t.Run("binary body", func(t *testing.T) {
+		har := record(t, "read "+server.URL+"/binary to="+filepath.Join(t.TempDir(), "out.bin"))
+		content := har.Log.Entries[0].Response.Content
+		if content.Encoding != "base64" || content.Text != "/wD+" || content.Size != 3 {
+			t.Errorf("content = %+v", content)
+		}
+	})
+
+	t.Run("connection failure", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		path := filepath.Join(t.TempDir(), "trace.har")
+		if _, _, err := runCommand(t, "read "+closed.URL+"/ har="+path); err == nil {
+			t.Fatal("expected connection error")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var har harFile
+		if err := json.Unmarshal(data, &har); err != nil {
+			t.Fatal(err)
+		}
+		if len(har.Log.Entries) != 1 || har.Log.Entries[0].Response.Status != 0 || har.Log.Entries[0].Error == "" {
+			t.Errorf("entries = %+v", har.Log.Entries)
+		}
+	})
+
+	t.Run("duplicate clause", func(t *testing.T) {
+		if _, err := parser.Parse("read https://example.com har=a.har har=b.har"); err == nil {
+			t.Error("Parse() expected error for duplicate har=")
+		}
+	})
+}
+
+// hasHeader reports whether headers holds name with value.
+func hasHeader(headers []map[string]string, name, value string) bool {
+	for _, header := range headers {
+		if header["name"] == name && header["value"] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/jsonpath"
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+const storeJSON = `{
+  "store": {
+    "book": [
+      {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+      {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+      {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+      {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+    ],
+    "bicycle": {"color": "red", "price": 19.95}
+  },
+  "expensive": 10
+}`
+
+func TestJSONPathEvaluate(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(storeJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.store.bicycle.color", `["red"]`},
+		{"store.bicycle['color']", `["red"]`},
+		{"$.store.book[*].author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$..author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$.store.*.color", `["red"]`},
+		{"$..book[2].title", `["Moby Dick"]`},
+		{"$..book[-1].title", `["The Lord of the Rings"]`},
+		{"$..book[0,1].price", `[8.95,12.99]`},
+		{"$..book[:2].price", `[8.95,12.99]`},
+		{"$..book[1:3].price", `[12.99,8.99]`},
+		{"$..book[::-2].price", `[22.99,12.99]`},
+		{"$..book[?(@.isbn)].title", `["Moby Dick","The Lord of the Rings"]`},
+		{"$..book[?(@.price < 10)].title", `["Sayings of the Century","Moby Dick"]`},
+		{"$..book[?(@.price > $.expensive && @.category == 'fiction')].price", `[12.99,22.99]`},
+		{`$..book[?(@.author =~ /tolkien/i)].price`, `[22.99]`},
+		{"$..book[?(!@.isbn)].price", `[8.95,12.99]`},
+		{"$.store.book.length", `[4]`},
+		{"$..missing", `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := jsonpath.Parse(tt.path)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, _ := json.Marshal(path.Evaluate(data))
+			if string(got) != tt.want {
+				t.Errorf("Evaluate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "$.", "$[", "$[1:2:0]", "$[?(@.a ==)]", "$['unterminated]", "$.a b"} {
+		if _, err := jsonpath.Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+	// Keywords are whole words, so the error names the word rather than stray text after null
+	if _, err := jsonpath.Parse("$[?(@.x == nullable)]"); err == nil || !strings.Contains(err.Error(), `"nullable"`) {
+		t.Errorf("Parse() error = %v, want it to name nullable", err)
+	}
+	if !mustParsePath(t, "$.a[0]['b']").Singular() || mustParsePath(t, "$.a[*]").Singular() {
+		t.Errorf("Singular() misclassified paths")
+	}
+}
+
+// mustParsePath parses a JSONPath expression the test relies on being valid.
+func mustParsePath(t *testing.T, expr string) *jsonpath.Path {
+	t.Helper()
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", expr, err)
+	}
+	return path
+}
+
+// TestPickOutput tests that pick= narrows the response and prints scalars raw.
+func TestPickOutput(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/store", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 9007199254740993, "store": ` + strings.SplitN(storeJSON, `"store": `, 2)[1]))
+	})
+
+	tests := []struct {
+		cmdStr string
+		want   string
+	}{
+		{"read " + ts.URL() + "/store pick=$.store.bicycle.color", "red\n"},
+		{"read " + ts.URL() + "/store pick=$.id", "9007199254740993\n"},
+		{"read " + ts.URL() + "/store pick=$..book[?(@.price<9)].title as=json", "[\n  \"Sayings of the Century\",\n  \"Moby Dick\"\n]\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, err := runCommand(t, tt.cmdStr)
+		if err != nil {
+			t.Fatalf("%s: Execute() error = %v\nstderr: %s", tt.cmdStr, err, stderr)
+		}
+		if stdout != tt.want {
+			t.Errorf("%s: stdout = %q, want %q", tt.cmdStr, stdout, tt.want)
+		}
+	}
+
+	for _, path := range []string{"/store pick=$.nope", "/status/200 pick=$.id"} {
+		_, _, err := runCommand(t, "read "+ts.URL()+path)
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 9 {
+			t.Errorf("%s: error = %v, want exit code 9", path, err)
+		}
+	}
+}
+
+func TestExpectJSONPathComparisons(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/cart", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items": [{"sku": "a1", "qty": 2}, {"sku": "b2", "qty": 1}], "user": {"role": "admin", "verified": true},
+			"token": "t0k", "price": 10.5, "version": "2", "coupon": null}`))
+	})
+
+	passing := []string{
+		"jsonpath:$.items.length>0",
+		"jsonpath:$.user.role==admin",
+		"jsonpath:$.token exists",
+		"jsonpath:$.price<=10.5",
+		"jsonpath:$.user.verified==true",
+		"jsonpath:$.coupon==null",
+		"jsonpath:$.version==2",
+		"jsonpath:$.items[*].qty>=1",
+		"jsonpath:$.token=~/^T0/i",
+		`'jsonpath:$.user.role != "guest"'`,
+	}
+	for _, check := range passing {
+		if _, stderr, err := runCommand(t, "read "+ts.URL()+"/cart expect="+check); err != nil {
+			t.Errorf("expect=%s: unexpected failure %v\nstderr: %s", check, err, stderr)
+		}
+	}
+
+	failing := map[string]string{
+		"jsonpath:$.price<10":          "expected $.price < 10, got 10.5",
+		"jsonpath:$.user.role==viewer": `expected $.user.role == viewer, got "admin"`,
+		"jsonpath:$.items[*].qty>1":    "expected $.items[*].qty > 1, got 1",
+		"jsonpath:$.refresh exists":    "expected $.refresh to exist",
+		`'jsonpath:$.version=="3"'`:    `got "2"`,
+	}
+	for check, message := range failing {
+		_, stderr, err := runCommand(t, "read "+ts.URL()+"/cart expect="+check)
+		execErr, ok := err.(*runtime.ExecutionError)
+		if !ok || execErr.Code != 3 {
+			t.Errorf("expect=%s: expected exit code 3, got %v", check, err)
+			continue
+		}
+		if !strings.Contains(stderr, message) {
+			t.Errorf("expect=%s: stderr %q does not mention %q", check, stderr, message)
+		}
+	}
+}
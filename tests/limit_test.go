@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// TestSizeLimit tests that under=<size> aborts oversized responses with exit code 7.
+func TestSizeLimit(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	// A tiny compressed body that expands to 1MB
+	ts.mux.HandleFunc("/bomb", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(bytes.Repeat([]byte{0}, 1024*1024))
+		gz.Close()
+	})
+	// Declares a large body up front, then stalls
+	ts.mux.HandleFunc("/declared", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10485760")
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	ts.mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.Write(bytes.Repeat([]byte("a"), 1024*1024))
+	})
+
+	tests := []struct {
+		name    string
+		cmdStr  string
+		wantErr bool
+	}{
+		{"within limit", "read " + ts.URL() + "/json under=1KB", false},
+		{"body over limit", "read " + ts.URL() + "/json under=10B", true},
+		{"decompressed over limit", "read " + ts.URL() + "/bomb under=100KB", true},
+		{"content-length over limit", "read " + ts.URL() + "/declared under=1MB", true},
+		{"inspect reads no body", "inspect " + ts.URL() + "/large under=1KB", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, _, err := runCommand(t, tt.cmdStr)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Execute() error = %v", err)
+				}
+				return
+			}
+
+			var execErr *runtime.ExecutionError
+			if !errors.As(err, &execErr) || execErr.Code != 7 {
+				t.Fatalf("expected exit code 7, got %v", err)
+			}
+			if !strings.Contains(execErr.Message, "exceeds size limit") {
+				t.Errorf("unexpected message: %s", execErr.Message)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("expected limit to be enforced without reading the body, took %s", elapsed)
+			}
+		})
+	}
+}
+
+// TestSizeLimitSave tests that an oversized save removes the partial file.
+func TestSizeLimitSave(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		// Stream without Content-Length so the limit trips mid-download
+		for i := 0; i < 64; i++ {
+			w.Write(bytes.Repeat([]byte("x"), 1024))
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	for _, flag := range []string{"", " resume"} {
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "large.bin")
+		_, _, err := runCommand(t, "save "+ts.URL()+"/large to="+dest+" under=16KB"+flag)
+
+		var execErr *runtime.ExecutionError
+		if !errors.As(err, &execErr) || execErr.Code != 7 {
+			t.Fatalf("save%s: expected exit code 7, got %v", flag, err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("save%s: expected no files left behind, found %d", flag, len(entries))
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// testCert is a generated certificate with its PEM encodings.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate for template, signed by parent or self-signed.
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// TestMutualTLS covers cert=, key= (plain and encrypted), ca= as a file or
+// directory, and servername= against a server that requires client certificates.
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "req test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "api.internal"},
+		DNSNames:    []string{"api.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	client := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "plain-client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	caFile := write("ca.pem", ca.certPEM)
+	clientCert := write("client.pem", client.certPEM)
+	clientKey := write("client.key", client.keyPEM)
+	combined := write("combined.pem", append(append([]byte{}, client.certPEM...), client.keyPEM...))
+	caDir := filepath.Join(dir, "cas")
+	os.Mkdir(caDir, 0700)
+	os.WriteFile(filepath.Join(caDir, "README"), []byte("not a certificate"), 0600)
+	os.WriteFile(filepath.Join(caDir, "internal.crt"), ca.certPEM, 0600)
+
+	// The encrypted fixture's certificate is self-signed, so trust it directly
+	encryptedCert := filepath.Join("fixtures", "tls", "client-encrypted.crt")
+	encryptedKey := filepath.Join("fixtures", "tls", "client-encrypted.key")
+	legacyKey := filepath.Join("fixtures", "tls", "client-legacy.key")
+	encryptedPEM, err := os.ReadFile(encryptedCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	clientCAs.AppendCertsFromPEM(encryptedPEM)
+	serverPair, err := tls.X509KeyPair(server.certPEM, server.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake failures
+	ts.StartTLS()
+	defer ts.Close()
+
+	trust := " ca=" + caFile + " servername=api.internal"
+
+	t.Run("client certificate", func(t *testing.T) {
+		for name, clauses := range map[string]string{
+			"separate key": trust + " cert=" + clientCert + " key=" + clientKey,
+			"combined PEM": trust + " cert=" + combined,
+			"CA directory": " ca=" + caDir + " servername=api.internal cert=" + clientCert + " key=" + clientKey,
+		} {
+			stdout, stderr, err := runCommand(t, "read "+ts.URL+clauses)
+			if err != nil {
+				t.Errorf("%s: error = %v\nstderr: %s", name, err, stderr)
+			} else if stdout != "plain-client" {
+				t.Errorf("%s: server saw client %q", name, stdout)
+			}
+		}
+	})
+
+	t.Run("resolve keeps SNI", func(t *testing.T) {
+		port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+		stdout, stderr, err := runCommand(t, "read https://api.internal:"+port+"/ resolve=api.internal:"+port+":127.0.0.1 ca="+caFile+" cert="+clientCert+" key="+clientKey)
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if stdout != "plain-client" {
+			t.Errorf("server saw client %q", stdout)
+		}
+	})
+
+	t.Run("encrypted key", func(t *testing.T) {
+		t.Setenv("REQ_KEY_PASSPHRASE", "req-test")
+		stdout, stderr, err := runCommand(t, "read "+ts.URL+trust+" cert="+encryptedCert+" key="+encryptedKey)
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if stdout != "encrypted-client" {
+			t.Errorf("server saw client %q", stdout)
+		}
+	})
+
+	t.Run("encrypted key errors", func(t *testing.T) {
+		newExecutor := func(t *testing.T) error {
+			t.Helper()
+			cmd, err := parser.Parse("read " + ts.URL + trust + " cert=" + encryptedCert + " key=" + encryptedKey)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			plan, err := planner.Plan(cmd)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			_, err = runtime.NewExecutor(plan)
+			return err
+		}
+
+		t.Setenv("REQ_KEY_PASSPHRASE", "")
+		os.Unsetenv("REQ_KEY_PASSPHRASE")
+		if err := newExecutor(t); err == nil || !strings.Contains(err.Error(), "REQ_KEY_PASSPHRASE") {
+			t.Errorf("missing passphrase error = %v", err)
+		}
+
+		t.Setenv("REQ_KEY_PASSPHRASE", "wrong")
+		if err := newExecutor(t); err == nil || !strings.Contains(err.Error(), "failed to decrypt client key") {
+			t.Errorf("wrong passphrase error = %v", err)
+		}
+	})
+
+	t.Run("legacy encrypted key rejected", func(t *testing.T) {
+		t.Setenv("REQ_KEY_PASSPHRASE", "req-test")
+		cmd, err := parser.Parse("read " + ts.URL + trust + " cert=" + encryptedCert + " key=" + legacyKey)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		plan, err := planner.Plan(cmd)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		if _, err = runtime.NewExecutor(plan); err == nil || !strings.Contains(err.Error(), "openssl pkcs8 -topk8 -in "+legacyKey) {
+			t.Errorf("error = %v, want a hint to convert the key to PKCS#8", err)
+		}
+	})
+
+	t.Run("handshake failures", func(t *testing.T) {
+		for name, clauses := range map[string]string{
+			"no client certificate":   trust,
+			"no server name override": " ca=" + caFile + " cert=" + clientCert + " key=" + clientKey,
+			"system roots only":       " servername=api.internal cert=" + clientCert + " key=" + clientKey,
+		} {
+			_, _, err := runCommand(t, "read "+ts.URL+clauses)
+			if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 4 {
+				t.Errorf("%s: error = %v, want exit code 4", name, err)
+			}
+		}
+	})
+
+	t.Run("plan shows paths only", func(t *testing.T) {
+		cmd, err := parser.Parse("read https://api.internal/ cert=" + clientCert + " key=" + clientKey + " ca=" + caDir + " servername=api.internal")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		plan, err := planner.Plan(cmd)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		data, _ := json.Marshal(plan)
+		for _, want := range []string{`"cert_file":"` + clientCert, `"key_file":"` + clientKey, `"ca_path":"` + caDir, `"server_name":"api.internal"`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("plan missing %s: %s", want, data)
+			}
+		}
+		if strings.Contains(string(data), "PRIVATE KEY") {
+			t.Errorf("plan contains key material: %s", data)
+		}
+	})
+
+	t.Run("key requires cert", func(t *testing.T) {
+		cmd, err := parser.Parse("read https://api.internal/ key=" + clientKey)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if _, err := planner.Plan(cmd); err == nil {
+			t.Error("Plan() expected error for key= without cert=")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// multipartRecorder captures how a multipart upload arrived at the server.
+type multipartRecorder struct {
+	mu               sync.Mutex
+	contentLength    int64
+	transferEncoding []string
+	parts            map[string]string // name -> "filename|content-type|content"
+}
+
+func (rec *multipartRecorder) handle(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	parts := make(map[string]string)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(part)
+		parts[part.FormName()] = part.FileName() + "|" + part.Header.Get("Content-Type") + "|" + string(data)
+	}
+
+	rec.mu.Lock()
+	rec.contentLength = r.ContentLength
+	rec.transferEncoding = r.TransferEncoding
+	rec.parts = parts
+	rec.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+// TestMultipartStreaming tests that uploads declare Content-Length when sizes are known
+// and infer per-part content types from file extensions.
+func TestMultipartStreaming(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	rec := &multipartRecorder{}
+	ts.mux.HandleFunc("/upload", rec.handle)
+
+	dir := t.TempDir()
+	avatar := filepath.Join(dir, "avatar.png")
+	meta := filepath.Join(dir, "meta.json")
+	os.WriteFile(avatar, []byte("\x89PNG fake image"), 0644)
+	os.WriteFile(meta, []byte(`{"title":"clip"}`), 0644)
+
+	cmdStr := "upload " + ts.URL() + "/upload attach='part: name=avatar, file=@" + avatar +
+		"; part: name=meta, file=@" + meta + ", filename=info.txt, type=application/vnd.custom+json" +
+		"; part: name=note, value=hello'"
+	_, stderr, err := runCommand(t, cmdStr)
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.contentLength <= 0 || len(rec.transferEncoding) != 0 {
+		t.Errorf("expected a precomputed Content-Length, got %d (transfer encoding %v)", rec.contentLength, rec.transferEncoding)
+	}
+	want := map[string]string{
+		"avatar": "avatar.png|image/png|\x89PNG fake image",
+		"meta":   `info.txt|application/vnd.custom+json|{"title":"clip"}`,
+		"note":   "||hello",
+	}
+	for name, expected := range want {
+		if got := rec.parts[name]; got != expected {
+			t.Errorf("part %s = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+// TestMultipartChunked tests that parts of unknown size fall back to chunked transfer.
+func TestMultipartChunked(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	rec := &multipartRecorder{}
+	ts.mux.HandleFunc("/upload", rec.handle)
+
+	stdinR, stdinW, _ := os.Pipe()
+	oldStdin := os.Stdin
+	os.Stdin = stdinR
+	defer func() { os.Stdin = oldStdin }()
+	go func() {
+		io.WriteString(stdinW, strings.Repeat("log line\n", 1000))
+		stdinW.Close()
+	}()
+
+	_, stderr, err := runCommand(t, "upload "+ts.URL()+"/upload attach='part: name=log, file=@-, filename=build-output'")
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.transferEncoding) == 0 || rec.transferEncoding[0] != "chunked" {
+		t.Errorf("expected chunked transfer encoding, got %v (Content-Length %d)", rec.transferEncoding, rec.contentLength)
+	}
+	if got := rec.parts["log"]; got != "build-output|application/octet-stream|"+strings.Repeat("log line\n", 1000) {
+		t.Errorf("unexpected log part: %.60q", got)
+	}
+}
+
+// TestMultipartNotSent tests that a multipart body whose request fails before it
+// is sent leaves no writer goroutine behind.
+func TestMultipartNotSent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "avatar.png")
+	os.WriteFile(file, []byte("\x89PNG fake image"), 0644)
+	missing := filepath.Join(t.TempDir(), "cookies.txt")
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 5; i++ {
+		_, _, err := runCommand(t, "upload http://127.0.0.1:1/upload attach='part: name=avatar, file=@"+file+"' cookies=@"+missing)
+		if err == nil {
+			t.Fatal("expected the missing cookie file to fail the request")
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if leaked := runtime.NumGoroutine() - before; leaked >= 5 {
+		t.Errorf("%d goroutines left running after unsent uploads", leaked)
+	}
+}
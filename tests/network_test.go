@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+)
+
+// newLoopbackServer starts a server on addr ("127.0.0.1:0" or "[::1]:0") that
+// reports the Host header and the client's address.
+func newLoopbackServer(t *testing.T, addr string) *httptest.Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote, _, _ := net.SplitHostPort(r.RemoteAddr)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"host": r.Host, "remote": remote})
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	return server
+}
+
+// TestNetworkRouting covers resolve=, ip= and bind= against loopback servers.
+func TestNetworkRouting(t *testing.T) {
+	v4 := newLoopbackServer(t, "127.0.0.1:0")
+	defer v4.Close()
+	v6 := newLoopbackServer(t, "[::1]:0")
+	defer v6.Close()
+	_, v4Port, _ := net.SplitHostPort(v4.Listener.Addr().String())
+	_, v6Port, _ := net.SplitHostPort(v6.Listener.Addr().String())
+
+	request := func(t *testing.T, command string) (map[string]string, error) {
+		t.Helper()
+		stdout, _, err := runCommand(t, command)
+		if err != nil {
+			return nil, err
+		}
+		var got map[string]string
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout, err)
+		}
+		return got, nil
+	}
+
+	t.Run("resolve keeps Host", func(t *testing.T) {
+		tests := []struct {
+			name, command, wantHost, wantRemote string
+		}{
+			{"IPv4", fmt.Sprintf("read http://api.test:%s/ resolve=api.test:%s:127.0.0.1", v4Port, v4Port), "api.test:" + v4Port, "127.0.0.1"},
+			{"IPv6", fmt.Sprintf("read http://api.test:%s/ resolve=api.test:%s:[::1]", v6Port, v6Port), "api.test:" + v6Port, "::1"},
+			{"connect-to port", fmt.Sprintf("read http://backend.test/ resolve=backend.test:80:127.0.0.1:%s", v4Port), "backend.test", "127.0.0.1"},
+			{"several rules", fmt.Sprintf("read http://b.test:%s/ resolve='a.test:80:10.0.0.1;b.test:%s:[::1]'", v6Port, v6Port), "b.test:" + v6Port, "::1"},
+		}
+		for _, tt := range tests {
+			got, err := request(t, tt.command)
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+				continue
+			}
+			if got["host"] != tt.wantHost || got["remote"] != tt.wantRemote {
+				t.Errorf("%s: server saw %v, want host %s from %s", tt.name, got, tt.wantHost, tt.wantRemote)
+			}
+		}
+	})
+
+	t.Run("ip family", func(t *testing.T) {
+		if _, err := request(t, "read http://[::1]:"+v6Port+"/ ip=6"); err != nil {
+			t.Errorf("ip=6 to ::1: error = %v", err)
+		}
+		if _, err := request(t, "read http://127.0.0.1:"+v4Port+"/ ip=4"); err != nil {
+			t.Errorf("ip=4 to 127.0.0.1: error = %v", err)
+		}
+		if _, err := request(t, "read http://[::1]:"+v6Port+"/ ip=4"); err == nil {
+			t.Error("ip=4 to ::1 expected error")
+		}
+		if _, err := request(t, "read http://127.0.0.1:"+v4Port+"/ ip=6"); err == nil {
+			t.Error("ip=6 to 127.0.0.1 expected error")
+		}
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		got, err := request(t, "read http://[::1]:"+v6Port+"/ bind=::1")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if got["remote"] != "::1" {
+			t.Errorf("server saw client %s, want ::1", got["remote"])
+		}
+		if _, err := request(t, "read http://[::1]:"+v6Port+"/ bind=127.0.0.1"); err == nil {
+			t.Error("IPv4 bind to an IPv6 server expected error")
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		cmd, err := parser.Parse("read https://api.example.com/ resolve=api.example.com:443:10.0.0.5 resolve=localhost:8080:[::1]:9090 ip=4 bind=10.0.0.2")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		plan, err := planner.Plan(cmd)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		data, _ := json.Marshal(plan.Network)
+		want := `{"resolve":{"api.example.com:443":"10.0.0.5:443","localhost:8080":"[::1]:9090"},"ip_version":"4","local_address":"10.0.0.2"}`
+		if string(data) != want {
+			t.Errorf("network plan = %s, want %s", data, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, command := range []string{
+			"read https://a.test resolve=a.test:10.0.0.1",
+			"read https://a.test resolve=a.test:https:10.0.0.1",
+			"read https://a.test resolve=a.test:443:",
+			"read https://a.test ip=5",
+			"read https://a.test bind=eth0",
+		} {
+			if _, err := parser.Parse(command); err == nil {
+				t.Errorf("Parse(%q) expected error", command)
+			}
+		}
+
+		cmd, err := parser.Parse("read https://a.test ip=6 bind=127.0.0.1")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if _, err := planner.Plan(cmd); err == nil || !strings.Contains(err.Error(), "IPv6") {
+			t.Errorf("Plan() error = %v, want family mismatch", err)
+		}
+	})
+}
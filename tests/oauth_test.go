@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/session"
+)
+
+// TestOAuthClientCredentials covers authenticate with grant=client_credentials
+// storing the token endpoint and client, and an expired token being replaced by
+// a new grant before the next request.
+func TestOAuthClientCredentials(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	host, _ := session.ExtractHost(ts.URL())
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	_, stderr, err := runCommand(t, "authenticate "+ts.URL()+"/oauth/token grant='client_credentials:read write' include='basic: "+testClientID+":"+testClientSecret+"'")
+	if err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+
+	sess, err := session.LoadSession(host)
+	if err != nil || sess == nil || sess.OAuth == nil {
+		t.Fatalf("LoadSession() = %+v, %v", sess, err)
+	}
+	if sess.Authorization != "Bearer access-1" {
+		t.Errorf("Authorization = %q", sess.Authorization)
+	}
+	oauth := sess.OAuth
+	if oauth.TokenEndpoint != ts.URL()+"/oauth/token" || oauth.GrantType != "client_credentials" || oauth.Scope != "read write" ||
+		oauth.ClientID != testClientID || oauth.ClientSecret != testClientSecret || oauth.RefreshToken != "" {
+		t.Errorf("oauth = %+v", oauth)
+	}
+	if until := time.Until(oauth.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("expires in %v, want about an hour", until)
+	}
+	if redacted := session.RedactSession(sess); redacted.OAuth.ClientSecret != "***" || sess.OAuth.ClientSecret != testClientSecret {
+		t.Errorf("redacted client secret = %q, original = %q", redacted.OAuth.ClientSecret, sess.OAuth.ClientSecret)
+	}
+
+	stdout, _, err := runCommand(t, "read "+ts.URL()+"/oauth/protected")
+	if err != nil || stdout != "token:access-1" {
+		t.Fatalf("read = %q, %v", stdout, err)
+	}
+
+	// Expire the token on both sides; the next request asks for a new one
+	ts.RevokeAccessTokens()
+	sess.OAuth.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := session.SaveSession(sess); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err = runCommand(t, "read "+ts.URL()+"/oauth/protected")
+	if err != nil || stdout != "token:access-2" {
+		t.Fatalf("read after expiry = %q, %v\nstderr: %s", stdout, err, stderr)
+	}
+	if !strings.Contains(stderr, "Refreshed access token for "+host) {
+		t.Errorf("stderr missing refresh note:\n%s", stderr)
+	}
+	if grants := strings.Join(ts.Grants(), ","); grants != "client_credentials,client_credentials" {
+		t.Errorf("grants = %s", grants)
+	}
+	if saved, _ := session.LoadSession(host); saved.Authorization != "Bearer access-2" || time.Until(saved.OAuth.ExpiresAt) < 59*time.Minute {
+		t.Errorf("saved session = %+v, %+v", saved, saved.OAuth)
+	}
+}
+
+// TestOAuthRefreshToken covers renewing a token that is about to expire with its
+// refresh token, keeping the rotated refresh token, and a failed refresh falling
+// back to the stored token.
+func TestOAuthRefreshToken(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	host, _ := session.ExtractHost(ts.URL())
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	// Tokens that expire within the refresh margin are renewed on next use
+	ts.SetTokenLifetime(10 * time.Second)
+	if _, stderr, err := runCommand(t, "authenticate "+ts.URL()+`/oauth/token with='{"username":"adam","password":"secret"}'`); err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+	sess, _ := session.LoadSession(host)
+	if sess == nil || sess.OAuth == nil || sess.OAuth.RefreshToken != "refresh-1" || sess.OAuth.ClientID != "" {
+		t.Fatalf("session = %+v", sess)
+	}
+
+	// The refresh goes out on its own client, so verbose and har= don't record it
+	ts.SetTokenLifetime(time.Hour)
+	harPath := filepath.Join(t.TempDir(), "trace.har")
+	stdout, stderr, err := runCommand(t, "read "+ts.URL()+"/oauth/protected verbose=unredacted har="+harPath)
+	if err != nil || stdout != "token:access-2" {
+		t.Fatalf("read = %q, %v\nstderr: %s", stdout, err, stderr)
+	}
+	if strings.Contains(stderr, "/oauth/token") || strings.Contains(stderr, "refresh-1") {
+		t.Errorf("verbose shows the token request:\n%s", stderr)
+	}
+	if har, err := os.ReadFile(harPath); err != nil || strings.Contains(string(har), "/oauth/token") || strings.Contains(string(har), "refresh-1") {
+		t.Errorf("HAR records the token request (%v):\n%s", err, har)
+	}
+	if grants := strings.Join(ts.Grants(), ","); grants != "password,refresh_token" {
+		t.Errorf("grants = %s", grants)
+	}
+	sess, _ = session.LoadSession(host)
+	if sess.Authorization != "Bearer access-2" || sess.OAuth.RefreshToken != "refresh-2" {
+		t.Errorf("session after refresh = %q, %+v", sess.Authorization, sess.OAuth)
+	}
+
+	// A fresh token is sent as is
+	stdout, _, _ = runCommand(t, "read "+ts.URL()+"/oauth/protected")
+	if stdout != "token:access-2" || len(ts.Grants()) != 2 {
+		t.Errorf("read = %q with grants %v, want no refresh", stdout, ts.Grants())
+	}
+
+	t.Run("failed refresh keeps the token", func(t *testing.T) {
+		sess.OAuth.RefreshToken = "revoked"
+		sess.OAuth.ExpiresAt = time.Now().Add(-time.Minute)
+		if err := session.SaveSession(sess); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, _ := runCommand(t, "read "+ts.URL()+"/oauth/protected")
+		if !strings.Contains(stderr, "Warning: failed to refresh access token") {
+			t.Errorf("stderr missing warning:\n%s", stderr)
+		}
+		if stdout != "token:access-2" {
+			t.Errorf("stdout = %q, want the stored token sent", stdout)
+		}
+		if saved, _ := session.LoadSession(host); saved.OAuth.RefreshToken != "revoked" {
+			t.Errorf("session changed by failed refresh: %+v", saved.OAuth)
+		}
+	})
+}
+
+// TestOAuthRefreshPrivateCA covers renewing a token at an endpoint whose
+// certificate is signed by a private CA given with ca=.
+func TestOAuthRefreshPrivateCA(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+	server := httptest.NewTLSServer(ts.mux)
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	host, _ := session.ExtractHost(server.URL)
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	ts.SetTokenLifetime(10 * time.Second)
+	if _, stderr, err := runCommand(t, "authenticate "+server.URL+`/oauth/token with='{"username":"adam","password":"secret"}' ca=`+caFile); err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+	ts.SetTokenLifetime(time.Hour)
+	stdout, stderr, err := runCommand(t, "read "+server.URL+"/oauth/protected ca="+caFile)
+	if err != nil || stdout != "token:access-2" || !strings.Contains(stderr, "Refreshed access token") {
+		t.Errorf("read = %q, %v\nstderr: %s", stdout, err, stderr)
+	}
+}
+
+// TestOAuthLoginStoresNoPassword covers a login that sends the user's password as
+// Basic auth: the refresh token is kept, but the password never reaches the
+// session file.
+func TestOAuthLoginStoresNoPassword(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	host, _ := session.ExtractHost(ts.URL())
+	session.DeleteSession(host)
+	defer session.DeleteSession(host)
+
+	if _, stderr, err := runCommand(t, "authenticate "+ts.URL()+`/oauth/token with='{"username":"adam","password":"form-pass"}' include='basic: adam:hunter2'`); err != nil {
+		t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+	}
+	sess, err := session.LoadSession(host)
+	if err != nil || sess == nil || sess.OAuth == nil || sess.OAuth.RefreshToken != "refresh-1" {
+		t.Fatalf("session = %+v, %v", sess, err)
+	}
+	if sess.OAuth.ClientID != "" || sess.OAuth.ClientSecret != "" || sess.OAuth.GrantType != "" {
+		t.Errorf("oauth = %+v, want no client credentials", sess.OAuth)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".config", "req", "session_"+strings.ReplaceAll(host, ":", "_")+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("session file holds the password:\n%s", data)
+	}
+}
+
+// TestGrantClause covers parsing and planning grant=.
+func TestGrantClause(t *testing.T) {
+	cmd, err := parser.Parse("authenticate https://auth.example.com/token grant=client_credentials:read,write include='basic: id:secret'")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	plan, err := planner.Plan(cmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.Method != "POST" || plan.Body == nil || plan.Body.Type != "form" || plan.Body.Content != "grant_type=client_credentials&scope=read+write" {
+		t.Errorf("plan = %s %+v", plan.Method, plan.Body)
+	}
+
+	for name, input := range map[string]string{
+		"unsupported grant": "authenticate https://auth.example.com/token grant=password include='basic: id:secret'",
+		"missing client":    "authenticate https://auth.example.com/token grant=client_credentials",
+		"with body":         "authenticate https://auth.example.com/token grant=client_credentials include='basic: id:secret' with='{}'",
+		"not authenticate":  "read https://auth.example.com/token grant=client_credentials include='basic: id:secret'",
+	} {
+		t.Run(name, func(t *testing.T) {
+			cmd, err := parser.Parse(input)
+			if err != nil {
+				return
+			}
+			if _, err := planner.Plan(cmd); err == nil {
+				t.Errorf("expected an error for %s", input)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"testing"
+	"time"
 
 	"github.com/adammpkins/req/internal/parser"
 	"github.com/adammpkins/req/internal/types"
@@ -60,6 +61,20 @@ func TestParseBasicRead(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:  "flag after body",
+			input: "send https://api.example.com/users with='{\"name\":\"Ada\"}' verbose=unredacted timing",
+			want: &types.Command{
+				Verb:   types.VerbSend,
+				Target: types.Target{URL: "https://api.example.com/users"},
+				Clauses: []types.Clause{
+					types.WithClause{Type: "json", Value: "'{\"name\":\"Ada\"}'"},
+					types.VerboseClause{ShowCredentials: true},
+					types.TimingClause{Format: "text"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:  "save with destination",
 			input: "save https://example.com/file.zip to=file.zip",
@@ -419,3 +434,174 @@ func TestParseBasicAuth(t *testing.T) {
 	}
 }
 
+
+func TestParseWatchPolling(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+		check   func(*testing.T, *types.Command)
+	}{
+		{
+			name:    "every and until status",
+			input:   `watch https://api.example.com/jobs/1 every=5s until=status:200`,
+			wantErr: false,
+			check: func(t *testing.T, cmd *types.Command) {
+				if len(cmd.Clauses) != 2 {
+					t.Fatalf("expected 2 clauses, got %d", len(cmd.Clauses))
+				}
+				every, ok := cmd.Clauses[0].(types.EveryClause)
+				if !ok {
+					t.Fatalf("expected EveryClause, got %T", cmd.Clauses[0])
+				}
+				if every.Interval.String() != "5s" {
+					t.Errorf("expected interval 5s, got %s", every.Interval)
+				}
+				until, ok := cmd.Clauses[1].(types.UntilClause)
+				if !ok {
+					t.Fatalf("expected UntilClause, got %T", cmd.Clauses[1])
+				}
+				if until.Check.Type != "status" || until.Check.Value != "200" {
+					t.Errorf("expected status:200 check, got %+v", until.Check)
+				}
+			},
+		},
+		{
+			name:    "until jsonpath equals",
+			input:   `watch https://api.example.com/jobs/1 until=jsonpath:$.state==succeeded every=1s`,
+			wantErr: false,
+			check: func(t *testing.T, cmd *types.Command) {
+				until := cmd.Clauses[0].(types.UntilClause)
+				if until.Check.Type != "jsonpath" || until.Check.Path != "$.state" || until.Check.Value != "succeeded" {
+					t.Errorf("expected jsonpath $.state==succeeded, got %+v", until.Check)
+				}
+			},
+		},
+		{
+			name:    "until contains quoted",
+			input:   `watch https://api.example.com/jobs/1 until='contains:"done"'`,
+			wantErr: false,
+			check: func(t *testing.T, cmd *types.Command) {
+				until := cmd.Clauses[0].(types.UntilClause)
+				if until.Check.Type != "contains" || until.Check.Value != "done" {
+					t.Errorf("expected contains:done, got %+v", until.Check)
+				}
+			},
+		},
+		{
+			name:    "until unknown check",
+			input:   `watch https://api.example.com/jobs/1 until=bogus:1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := parser.Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && tt.check != nil {
+				tt.check(t, cmd)
+			}
+		})
+	}
+}
+
+func TestParseRetryAndBackoff(t *testing.T) {
+	cmd, err := parser.Parse(`read https://api.example.com/users retry=5 backoff=100ms..2s`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	retry, ok := cmd.Clauses[0].(types.RetryClause)
+	if !ok || retry.Count != 5 || retry.AllowNonIdempotent {
+		t.Errorf("expected RetryClause{Count: 5}, got %+v", cmd.Clauses[0])
+	}
+	backoff, ok := cmd.Clauses[1].(types.BackoffClause)
+	if !ok || backoff.Min != 100*time.Millisecond || backoff.Max != 2*time.Second {
+		t.Errorf("expected BackoffClause{100ms, 2s}, got %+v", cmd.Clauses[1])
+	}
+
+	cmd, err = parser.Parse(`send https://api.example.com/users with='{}' retry=2:always`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if retry := cmd.Clauses[1].(types.RetryClause); retry.Count != 2 || !retry.AllowNonIdempotent {
+		t.Errorf("expected RetryClause{Count: 2, AllowNonIdempotent: true}, got %+v", retry)
+	}
+
+	for _, input := range []string{
+		`read https://api.example.com retry=many`,
+		`read https://api.example.com retry=3:sometimes`,
+		`read https://api.example.com backoff=5s`,
+		`read https://api.example.com backoff=5s..1s`,
+	} {
+		if _, err := parser.Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseUnderSize(t *testing.T) {
+	sizes := map[string]int64{"512B": 512, "10KB": 10 * 1024, "1.5MB": 1536 * 1024, "2GB": 2 << 30}
+	for value, want := range sizes {
+		cmd, err := parser.Parse("save https://example.com/file.bin under=" + value)
+		if err != nil {
+			t.Fatalf("Parse(under=%s) error = %v", value, err)
+		}
+		under := cmd.Clauses[0].(types.UnderClause)
+		if !under.IsSize || under.Size != want {
+			t.Errorf("under=%s parsed as %+v, want size %d", value, under, want)
+		}
+	}
+}
+
+func TestParseJSONPathChecks(t *testing.T) {
+	cmd, err := parser.Parse(`read https://api.example.com/cart expect=jsonpath:$.items.length>0, jsonpath:$.user.role==admin, jsonpath:$.token exists, jsonpath:$.price<=10.5, jsonpath:$.items[?(@.qty>1)].sku!=null`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	checks := cmd.Clauses[0].(types.ExpectClause).Checks
+	want := []types.ExpectCheck{
+		{Type: "jsonpath", Path: "$.items.length", Op: ">", Value: "0", Expected: 0.0},
+		{Type: "jsonpath", Path: "$.user.role", Op: "==", Value: "admin", Expected: "admin"},
+		{Type: "jsonpath", Path: "$.token", Op: "exists"},
+		{Type: "jsonpath", Path: "$.price", Op: "<=", Value: "10.5", Expected: 10.5},
+		{Type: "jsonpath", Path: "$.items[?(@.qty>1)].sku", Op: "!=", Value: "null", Expected: nil},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("got %d checks, want %d: %+v", len(checks), len(want), checks)
+	}
+	for i := range want {
+		if checks[i] != want[i] {
+			t.Errorf("check %d = %+v, want %+v", i, checks[i], want[i])
+		}
+	}
+
+	literals := map[string]interface{}{
+		`jsonpath:$.a==true`:      true,
+		`jsonpath:$.a=="42"`:      "42",
+		`jsonpath:$.a=~/^ab+/i`:   "(?i)^ab+",
+		`'jsonpath:$.a == "x y"'`: "x y",
+	}
+	for check, expected := range literals {
+		cmd, err := parser.Parse("read https://api.example.com expect=" + check)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", check, err)
+		}
+		if got := cmd.Clauses[0].(types.ExpectClause).Checks[0].Expected; got != expected {
+			t.Errorf("%s: Expected = %#v, want %#v", check, got, expected)
+		}
+	}
+
+	for _, input := range []string{
+		`read https://api.example.com expect=jsonpath:$.a==`,
+		`read https://api.example.com expect=jsonpath:$.a=~/[/`,
+		`read https://api.example.com expect=jsonpath:$.a[`,
+	} {
+		if _, err := parser.Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
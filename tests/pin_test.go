@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// TestPublicKeyPinning covers pin= against a server trusted through ca=, with
+// matching, rotated and mismatched pins.
+func TestPublicKeyPinning(t *testing.T) {
+	ca := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "req pin CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "api.internal"},
+		DNSNames:    []string{"api.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, ca.certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	serverPair, err := tls.X509KeyPair(server.certPEM, server.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, "pinned")
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{serverPair}}
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0) // expected handshake failures
+	ts.StartTLS()
+	defer ts.Close()
+
+	sum := sha256.Sum256(server.cert.RawSubjectPublicKeyInfo)
+	serverPin := "sha256//" + base64.StdEncoding.EncodeToString(sum[:])
+	otherSum := sha256.Sum256(ca.cert.RawSubjectPublicKeyInfo)
+	otherPin := "sha256//" + base64.StdEncoding.EncodeToString(otherSum[:])
+
+	base := "read " + ts.URL + " ca=" + caFile + " servername=api.internal"
+
+	t.Run("matching pins", func(t *testing.T) {
+		for _, clauses := range []string{
+			" pin=" + serverPin,
+			" pin='" + otherPin + ";" + serverPin + "'",
+			" pin=" + otherPin + " pin=" + serverPin,
+		} {
+			stdout, stderr, err := runCommand(t, base+clauses)
+			if err != nil {
+				t.Errorf("%s: error = %v\nstderr: %s", clauses, err, stderr)
+			} else if stdout != "pinned" {
+				t.Errorf("%s: stdout = %q", clauses, stdout)
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		requests.Store(0)
+		for _, clauses := range []string{
+			" pin=" + otherPin,
+			" pin=" + otherPin + " retry=2 backoff=1ms..2ms",
+			" pin=" + otherPin + " insecure=true",
+		} {
+			_, _, err := runCommand(t, base+clauses)
+			execErr, ok := err.(*runtime.ExecutionError)
+			if !ok || execErr.Code != 8 {
+				t.Errorf("%s: error = %v, want exit code 8", clauses, err)
+				continue
+			}
+			if !strings.Contains(execErr.Message, "server presented "+serverPin) {
+				t.Errorf("%s: message doesn't show the observed pin: %s", clauses, execErr.Message)
+			}
+		}
+		if n := requests.Load(); n != 0 {
+			t.Errorf("server handled %d requests despite pin mismatches", n)
+		}
+	})
+
+	t.Run("chain verification still applies", func(t *testing.T) {
+		_, _, err := runCommand(t, "read "+ts.URL+" servername=api.internal pin="+serverPin)
+		if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 4 {
+			t.Errorf("error = %v, want exit code 4 for an untrusted chain", err)
+		}
+	})
+
+	t.Run("invalid pins", func(t *testing.T) {
+		for _, command := range []string{
+			"read https://api.internal pin=abc",
+			"read https://api.internal pin=sha256//tooShort=",
+			"read https://api.internal pin=sha1//" + strings.Repeat("A", 28),
+		} {
+			if _, err := parser.Parse(command); err == nil {
+				t.Errorf("Parse(%q) expected error", command)
+			}
+		}
+
+		cmd, err := parser.Parse("read http://api.internal pin=" + serverPin)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if _, err := planner.Plan(cmd); err == nil {
+			t.Error("Plan() expected error for pin= on an http:// URL")
+		}
+	})
+}
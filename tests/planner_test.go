@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/adammpkins/req/internal/parser"
 	"github.com/adammpkins/req/internal/planner"
 	"github.com/adammpkins/req/internal/types"
 )
@@ -204,3 +205,85 @@ func TestPlanWithUsingDeleteForSend(t *testing.T) {
 	}
 }
 
+
+func TestPlanWatchPolling(t *testing.T) {
+	cmd := &types.Command{
+		Verb:   types.VerbWatch,
+		Target: types.Target{URL: "https://api.example.com/jobs/1"},
+		Clauses: []types.Clause{
+			types.EveryClause{Interval: 2 * time.Second},
+			types.UntilClause{Predicate: "status:200", Check: types.ExpectCheck{Type: "status", Value: "200"}},
+		},
+	}
+
+	plan, err := planner.Plan(cmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if plan.Poll == nil {
+		t.Fatal("Plan() Poll is nil")
+	}
+	if plan.Poll.Interval != 2*time.Second {
+		t.Errorf("Plan() Poll.Interval = %v, want 2s", plan.Poll.Interval)
+	}
+	if plan.Poll.Until == nil || plan.Poll.Until.Type != "status" {
+		t.Errorf("Plan() Poll.Until = %+v, want status check", plan.Poll.Until)
+	}
+}
+
+func TestPlanPollingRequiresWatch(t *testing.T) {
+	cmd := &types.Command{
+		Verb:   types.VerbRead,
+		Target: types.Target{URL: "https://api.example.com/jobs/1"},
+		Clauses: []types.Clause{
+			types.EveryClause{Interval: time.Second},
+		},
+	}
+
+	if _, err := planner.Plan(cmd); err == nil {
+		t.Fatal("Plan() expected error for every= on read verb")
+	}
+}
+
+func TestPlanResumeRequiresDestination(t *testing.T) {
+	cmd := &types.Command{
+		Verb:    types.VerbRead,
+		Target:  types.Target{URL: "https://example.com/nightly.iso"},
+		Clauses: []types.Clause{types.ResumeClause{}},
+	}
+	if _, err := planner.Plan(cmd); err == nil {
+		t.Fatal("Plan() expected error for resume without a destination")
+	}
+
+	cmd.Verb = types.VerbSave
+	plan, err := planner.Plan(cmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if !plan.Resume || plan.Output.Destination != "nightly.iso" {
+		t.Errorf("Plan() Resume = %v, Destination = %q", plan.Resume, plan.Output.Destination)
+	}
+}
+
+func TestPlanColumnsRequireTabularFormat(t *testing.T) {
+	cmd, err := parser.Parse("read https://example.com/users.json columns=id,name")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := planner.Plan(cmd); err == nil {
+		t.Fatal("Plan() expected error for columns= without as=csv")
+	}
+
+	cmd, err = parser.Parse("read https://example.com/users.json as=csv columns='id, address.city'")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	plan, err := planner.Plan(cmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if got := strings.Join(plan.Output.Columns, "|"); got != "id|address.city" {
+		t.Errorf("columns = %q, want id|address.city", got)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+)
+
+// TestHTTPVersionSelection covers http=1.1|2|h2c against TLS and cleartext
+// HTTP/2 servers, and the negotiated protocol reported in the meta.
+func TestHTTPVersionSelection(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	})
+
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	h2cServer := httptest.NewUnstartedServer(handler)
+	h2cServer.Config.Protocols = new(http.Protocols)
+	h2cServer.Config.Protocols.SetHTTP1(true)
+	h2cServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2cServer.Start()
+	defer h2cServer.Close()
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"TLS default stays on HTTP/1.1", "read " + tlsServer.URL + " insecure=true", "HTTP/1.1"},
+		{"TLS http=1.1", "read " + tlsServer.URL + " insecure=true http=1.1", "HTTP/1.1"},
+		{"TLS http=2", "read " + tlsServer.URL + " insecure=true http=2", "HTTP/2.0"},
+		{"cleartext http=2 falls back", "read " + h2cServer.URL + " http=2", "HTTP/1.1"},
+		{"cleartext http=h2c", "read " + h2cServer.URL + " http=h2c", "HTTP/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, tt.command)
+			if err != nil {
+				t.Fatalf("error = %v\nstderr: %s", err, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("server saw %q, want %q", stdout, tt.want)
+			}
+			if !strings.Contains(stderr, "Protocol: "+tt.want+"\n") {
+				t.Errorf("stderr missing protocol %s:\n%s", tt.want, stderr)
+			}
+		})
+	}
+
+	t.Run("inspect reports protocol", func(t *testing.T) {
+		_, stderr, err := runCommand(t, "inspect "+h2cServer.URL+" http=h2c")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if !strings.Contains(stderr, "Protocol: HTTP/2.0\n") {
+			t.Errorf("stderr missing protocol:\n%s", stderr)
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		if _, err := parser.Parse("read https://example.com http=3"); err == nil {
+			t.Error("Parse() expected error for http=3")
+		}
+	})
+
+	t.Run("h2c rejects https", func(t *testing.T) {
+		cmd, err := parser.Parse("read https://example.com http=h2c")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		plan, err := planner.Plan(cmd)
+		if err == nil {
+			t.Fatal("Plan() expected error for h2c over https")
+		}
+		if plan != nil {
+			t.Errorf("Plan() = %+v, want nil", plan)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// TestSaveResume tests that an interrupted download is continued with Range/If-Range.
+func TestSaveResume(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	var mu sync.Mutex
+	var ranges []string
+	interrupted := false
+	ts.mux.HandleFunc("/nightly.iso", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range")+"|"+r.Header.Get("If-Range"))
+		first := !interrupted
+		interrupted = true
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		if first {
+			// Promise the full body but drop the connection halfway through
+			w.Header().Set("Content-Length", "100000")
+			w.Write(content[:40000])
+			return
+		}
+		http.ServeContent(w, r, "nightly.iso", time.Unix(0, 0), bytes.NewReader(content))
+	})
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "nightly.iso")
+	cmdStr := "save " + ts.URL() + "/nightly.iso to=" + dest + " resume"
+
+	_, stderr, err := runCommand(t, cmdStr)
+	if err == nil {
+		t.Fatalf("expected interrupted download to fail")
+	}
+	if !strings.Contains(stderr+err.Error(), "rerun with resume") {
+		t.Errorf("expected hint to resume, got: %v", err)
+	}
+	if info, err := os.Stat(dest + ".part"); err != nil || info.Size() != 40000 {
+		t.Fatalf("expected 40000-byte partial file, got %v %v", info, err)
+	}
+
+	_, stderr, err = runCommand(t, cmdStr)
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Resuming download at 40000 bytes") {
+		t.Errorf("expected resume note in stderr, got: %s", stderr)
+	}
+	mu.Lock()
+	resumed := ranges[1]
+	mu.Unlock()
+	if resumed != `bytes=40000-|"v1"` {
+		t.Errorf("expected Range and If-Range on resume, got %q", resumed)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("resumed file does not match content (%d bytes, err %v)", len(data), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected partial and state files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+// TestSaveResumeChanged tests that a changed resource restarts the download from scratch.
+func TestSaveResumeChanged(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	content := []byte("fresh nightly build contents")
+	ts.mux.HandleFunc("/nightly.iso", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "nightly.iso", time.Unix(0, 0), bytes.NewReader(content))
+	})
+
+	dest := filepath.Join(t.TempDir(), "nightly.iso")
+	os.WriteFile(dest+".part", []byte("stale"), 0644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+ts.URL()+`/nightly.iso","etag":"\"v1\""}`), 0644)
+
+	_, stderr, err := runCommand(t, "save "+ts.URL()+"/nightly.iso to="+dest+" resume")
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "restarting download") {
+		t.Errorf("expected restart note, got: %s", stderr)
+	}
+	if data, _ := os.ReadFile(dest); !bytes.Equal(data, content) {
+		t.Errorf("expected fresh content, got %q", data)
+	}
+}
+
+// TestSaveResumeUnsatisfiable tests that a 416 response produces a clear error.
+func TestSaveResumeUnsatisfiable(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/nightly.iso", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "nightly.iso", time.Unix(0, 0), strings.NewReader("short"))
+	})
+
+	dest := filepath.Join(t.TempDir(), "nightly.iso")
+	os.WriteFile(dest+".part", []byte("longer than the resource"), 0644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+ts.URL()+`/nightly.iso","etag":"\"v1\""}`), 0644)
+
+	_, _, err := runCommand(t, "save "+ts.URL()+"/nightly.iso to="+dest+" resume")
+	var execErr *runtime.ExecutionError
+	if !errors.As(err, &execErr) || execErr.Code != 4 || !strings.Contains(execErr.Message, "416") {
+		t.Fatalf("expected exit code 4 with 416 message, got %v", err)
+	}
+	if _, err := os.Stat(dest + ".part"); err != nil {
+		t.Errorf("expected partial file to be kept: %v", err)
+	}
+}
+
+// TestSaveResumeExpectFailure tests that a completed download rejected by expect=
+// is dropped along with its resume state, so the next run starts over.
+func TestSaveResumeExpectFailure(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/nightly.iso", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "nightly.iso", time.Unix(0, 0), strings.NewReader("nightly build"))
+	})
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "nightly.iso")
+	os.WriteFile(dest+".part", []byte("nightly"), 0644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+ts.URL()+`/nightly.iso","etag":"\"v1\""}`), 0644)
+
+	_, _, err := runCommand(t, "save "+ts.URL()+"/nightly.iso to="+dest+` resume expect=contains:"release"`)
+	var execErr *runtime.ExecutionError
+	if !errors.As(err, &execErr) || execErr.Code != 3 {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no destination, partial or state file, found %d entries", len(entries))
+	}
+}
+
+// TestSaveResumeSizeLimit tests that under=<size> applies to the whole file, so
+// a download cannot grow past the limit over several resumed runs.
+func TestSaveResumeSizeLimit(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	ts.mux.HandleFunc("/declared.iso", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "declared.iso", time.Unix(0, 0), bytes.NewReader(content))
+	})
+	ts.mux.HandleFunc("/streamed.iso", func(w http.ResponseWriter, r *http.Request) {
+		// No Content-Length, so only the streamed byte count can trip the limit
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Range", "bytes 40000-99999/100000")
+		w.WriteHeader(http.StatusPartialContent)
+		w.(http.Flusher).Flush()
+		w.Write(content[40000:])
+	})
+
+	for _, name := range []string{"declared.iso", "streamed.iso"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, name)
+			os.WriteFile(dest+".part", content[:40000], 0644)
+			os.WriteFile(dest+".part.json", []byte(`{"url":"`+ts.URL()+`/`+name+`","etag":"\"v1\""}`), 0644)
+
+			_, _, err := runCommand(t, "save "+ts.URL()+"/"+name+" to="+dest+" resume under=70KB")
+			var execErr *runtime.ExecutionError
+			if !errors.As(err, &execErr) || execErr.Code != 7 {
+				t.Fatalf("expected exit code 7, got %v", err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("expected the oversized download to be dropped, found %d entries", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// flakyHandler fails with the given status until it has been called failures times.
+func flakyHandler(hits *int32, failures int32, status int, bodies chan<- string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if bodies != nil {
+			body, _ := io.ReadAll(r.Body)
+			bodies <- string(body)
+		}
+		if atomic.AddInt32(hits, 1) <= failures {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// TestRetryTransientStatus tests that 5xx responses are retried until one succeeds.
+func TestRetryTransientStatus(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var hits int32
+	ts.mux.HandleFunc("/flaky", flakyHandler(&hits, 2, http.StatusServiceUnavailable, nil))
+
+	stdout, stderr, err := runCommand(t, "read "+ts.URL()+"/flaky retry=3 backoff=10ms..20ms verbose")
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+	if !strings.Contains(stderr, "Attempt 1/4 failed (503 Service Unavailable)") || !strings.Contains(stderr, "Attempt 2/4 failed") {
+		t.Errorf("expected attempts logged to stderr, got: %s", stderr)
+	}
+	if !strings.Contains(stderr, "Attempts: 3") {
+		t.Errorf("expected attempt count in verbose output, got: %s", stderr)
+	}
+	if !strings.Contains(stdout, `"ok"`) {
+		t.Errorf("expected final response body, got: %s", stdout)
+	}
+}
+
+// TestRetryExhausted tests that the last response is reported once retries run out.
+func TestRetryExhausted(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var hits int32
+	ts.mux.HandleFunc("/down", flakyHandler(&hits, 100, http.StatusBadGateway, nil))
+
+	_, _, err := runCommand(t, "read "+ts.URL()+"/down retry=2 backoff=10ms..10ms")
+	var execErr *runtime.ExecutionError
+	if !errors.As(err, &execErr) || execErr.Code != 4 {
+		t.Fatalf("expected exit code 4, got %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+// TestRetryHonorsRetryAfter tests that a Retry-After header overrides the backoff delay.
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var hits int32
+	ts.mux.HandleFunc("/limited", flakyHandler(&hits, 1, http.StatusTooManyRequests, nil))
+
+	start := time.Now()
+	_, stderr, err := runCommand(t, "read "+ts.URL()+"/limited retry=1 backoff=10ms..2s")
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected Retry-After delay of 1s, finished after %s", elapsed)
+	}
+	if !strings.Contains(stderr, "retrying in 1s") {
+		t.Errorf("expected Retry-After delay in stderr, got: %s", stderr)
+	}
+
+	t.Run("capped at backoff max", func(t *testing.T) {
+		var hits int32
+		ts.mux.HandleFunc("/day", func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&hits, 1) == 1 {
+				w.Header().Set("Retry-After", "86400")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+
+		start := time.Now()
+		_, stderr, err := runCommand(t, "read "+ts.URL()+"/day retry=1 backoff=10ms..50ms")
+		if err != nil {
+			t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Retry-After not capped, finished after %s", elapsed)
+		}
+		if !strings.Contains(stderr, "retrying in 50ms") {
+			t.Errorf("expected capped delay in stderr, got: %s", stderr)
+		}
+	})
+}
+
+// TestRetryNonIdempotent tests that POST is only retried with retry=N:always, replaying the body.
+func TestRetryNonIdempotent(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var hits int32
+	bodies := make(chan string, 10)
+	ts.mux.HandleFunc("/flaky", flakyHandler(&hits, 1, http.StatusInternalServerError, bodies))
+
+	_, stderr, err := runCommand(t, "send "+ts.URL()+`/flaky with='{"n":1}' retry=3 backoff=10ms..10ms`)
+	if err == nil {
+		t.Fatalf("expected POST failure without retries")
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected POST to be sent once, got %d", n)
+	}
+	if !strings.Contains(stderr, "not retrying non-idempotent POST") {
+		t.Errorf("expected note about skipped retries, got: %s", stderr)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	<-bodies
+	_, stderr, err = runCommand(t, "send "+ts.URL()+`/flaky with='{"n":1}' retry=3:always backoff=10ms..10ms`)
+	if err != nil {
+		t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+	for i := 0; i < 2; i++ {
+		if body := <-bodies; body != `{"n":1}` {
+			t.Errorf("attempt %d sent body %q", i+1, body)
+		}
+	}
+}
+
+// TestRetryPermanentFailures tests that failures which won't change on retry are
+// reported after one attempt, while a refused connection is retried.
+func TestRetryPermanentFailures(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var hits int32
+	ts.mux.HandleFunc("/unimplemented", flakyHandler(&hits, 100, http.StatusNotImplemented, nil))
+	if _, stderr, _ := runCommand(t, "read "+ts.URL()+"/unimplemented retry=2 backoff=10ms..10ms"); strings.Contains(stderr, "Attempt 1/3") {
+		t.Errorf("501 retried: %s", stderr)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected 1 request for 501, got %d", n)
+	}
+
+	t.Run("certificate verification", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		_, stderr, err := runCommand(t, "read "+server.URL+" retry=2 backoff=10ms..10ms")
+		if err == nil || strings.Contains(stderr, "Attempt 1/3") {
+			t.Errorf("untrusted certificate retried (err %v): %s", err, stderr)
+		}
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := listener.Addr().String()
+		listener.Close()
+		_, stderr, err := runCommand(t, "read http://"+addr+" retry=1 backoff=10ms..10ms")
+		if err == nil || !strings.Contains(stderr, "Attempt 1/2 failed") {
+			t.Errorf("refused connection not retried (err %v): %s", err, stderr)
+		}
+	})
+}
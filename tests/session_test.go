@@ -80,8 +80,10 @@ func TestAuthenticateStoresSession(t *testing.T) {
 	}
 
 	// Verify cookie was captured
-	if sess.Cookies["session"] != "test-session-123" {
-		t.Errorf("Expected cookie 'session'='test-session-123', got %q", sess.Cookies["session"])
+	if len(sess.Cookies) != 1 || sess.Cookies[0].Name != "session" || sess.Cookies[0].Value != "test-session-123" {
+		t.Errorf("Expected cookie 'session'='test-session-123', got %+v", sess.Cookies)
+	} else if sess.Cookies[0].Path != "/" || !sess.Cookies[0].HostOnly {
+		t.Errorf("Expected host-only cookie for path /, got %+v", sess.Cookies[0])
 	}
 
 	// Verify access_token was captured
@@ -109,7 +111,7 @@ func TestAutoApplySession(t *testing.T) {
 	// Create a session manually
 	testSession := &session.Session{
 		Host:          host,
-		Cookies:       map[string]string{"session": "auto-applied-session"},
+		Cookies:       []session.Cookie{{Name: "session", Value: "auto-applied-session"}},
 		Authorization: "Bearer auto-applied-token",
 	}
 	if err := session.SaveSession(testSession); err != nil {
@@ -204,7 +206,7 @@ func TestSessionSuppression(t *testing.T) {
 	// Create a session manually
 	testSession := &session.Session{
 		Host:          host,
-		Cookies:       map[string]string{"session": "stored-session"},
+		Cookies:       []session.Cookie{{Name: "session", Value: "stored-session"}},
 		Authorization: "Bearer stored-token",
 	}
 	if err := session.SaveSession(testSession); err != nil {
@@ -298,7 +300,7 @@ func TestSessionSuppressionWithCookie(t *testing.T) {
 	// Create a session manually
 	testSession := &session.Session{
 		Host:          host,
-		Cookies:       map[string]string{"session": "stored-session"},
+		Cookies:       []session.Cookie{{Name: "session", Value: "stored-session"}},
 		Authorization: "Bearer stored-token",
 	}
 	if err := session.SaveSession(testSession); err != nil {
@@ -392,7 +394,7 @@ func TestSessionNotUsedMessage(t *testing.T) {
 	// Create a session manually
 	testSession := &session.Session{
 		Host:          host,
-		Cookies:       map[string]string{"session": "stored-session"},
+		Cookies:       []session.Cookie{{Name: "session", Value: "stored-session"}},
 		Authorization: "Bearer stored-token",
 	}
 	if err := session.SaveSession(testSession); err != nil {
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/planner"
+	"github.com/adammpkins/req/internal/session"
+	"github.com/adammpkins/req/internal/types"
+)
+
+// TestUnixSocketTargets covers unix:// targets and socket= for several verbs, and
+// sessions keyed by socket path.
+func TestUnixSocketTargets(t *testing.T) {
+	dir, err := os.MkdirTemp("", "req-sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "api.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"sock-token"}`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"host":   r.Host,
+			"uri":    r.URL.RequestURI(),
+			"body":   string(body),
+			"auth":   r.Header.Get("Authorization"),
+		})
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	decode := func(t *testing.T, stdout string) map[string]string {
+		t.Helper()
+		var got map[string]string
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("invalid JSON output %q: %v", stdout, err)
+		}
+		return got
+	}
+
+	t.Run("read unix target", func(t *testing.T) {
+		stdout, stderr, err := runCommand(t, "read unix://"+socketPath+":/containers/json?all=1")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		got := decode(t, stdout)
+		if got["uri"] != "/containers/json?all=1" || got["host"] != "localhost" {
+			t.Errorf("request = %v, want uri /containers/json?all=1 on host localhost", got)
+		}
+	})
+
+	t.Run("send with socket clause keeps Host", func(t *testing.T) {
+		stdout, stderr, err := runCommand(t, "send http://sidecar.internal/items socket="+socketPath+" with='{\"a\":1}'")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		got := decode(t, stdout)
+		if got["method"] != "POST" || got["host"] != "sidecar.internal" || got["body"] != `{"a":1}` {
+			t.Errorf("request = %v", got)
+		}
+	})
+
+	t.Run("sessions keyed by socket", func(t *testing.T) {
+		host := session.SocketHost(socketPath)
+		session.DeleteSession(host)
+		defer session.DeleteSession(host)
+
+		if _, stderr, err := runCommand(t, "authenticate unix://"+socketPath+":/login with='{}'"); err != nil {
+			t.Fatalf("authenticate error = %v\nstderr: %s", err, stderr)
+		}
+		stdout, stderr, err := runCommand(t, "read unix://"+socketPath+":/me")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if got := decode(t, stdout); got["auth"] != "Bearer sock-token" {
+			t.Errorf("Authorization = %q, want session token", got["auth"])
+		}
+		if !strings.Contains(stderr, "Using session for "+host) {
+			t.Errorf("stderr missing session note:\n%s", stderr)
+		}
+
+		// Plain localhost requests don't pick up the socket's session
+		if sess, _ := session.LoadSession("localhost"); sess != nil && sess.Authorization == "Bearer sock-token" {
+			t.Error("socket session stored under localhost")
+		}
+	})
+
+	t.Run("plan shows socket", func(t *testing.T) {
+		cmd, err := parser.Parse("read unix:///var/run/docker.sock:/containers/json")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		plan, err := planner.Plan(cmd)
+		if err != nil {
+			t.Fatalf("Plan() error = %v", err)
+		}
+		if plan.Socket != "/var/run/docker.sock" || plan.URL != "http://localhost/containers/json" {
+			t.Errorf("plan socket=%q url=%q", plan.Socket, plan.URL)
+		}
+	})
+
+	t.Run("socket conflicts", func(t *testing.T) {
+		cmd, err := parser.Parse("read unix:///a.sock:/x socket=/b.sock")
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if _, err := planner.Plan(cmd); err == nil {
+			t.Error("Plan() expected error for unix:// target with socket=")
+		}
+
+		cmd = &types.Command{
+			Verb:    types.VerbRead,
+			Target:  types.Target{URL: "http://localhost/x"},
+			Clauses: []types.Clause{types.SocketClause{Path: "/a.sock"}, types.ViaClause{URL: "http://proxy:8080"}},
+		}
+		if _, err := planner.Plan(cmd); err == nil {
+			t.Error("Plan() expected error for socket= with via=")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/runtime"
+)
+
+// TestTableOutput tests as=table alignment, truncation, column selection and width fitting.
+func TestTableOutput(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/broken.csv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("id,name\n1,Ada,extra\n"))
+	})
+	ts.mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id": 1, "name": "Ada", "bio": "Wrote the first published algorithm for the Analytical Engine", "address": {"city": "London"}},
+			{"id": 12, "name": "Grace\nHopper", "address": {"city": "New York"}}
+		]`))
+	})
+
+	tests := []struct {
+		name    string
+		cmdStr  string
+		columns string
+		want    string
+	}{
+		{
+			name:   "aligned with truncation",
+			cmdStr: "read " + ts.URL() + "/users as=table",
+			want: "" +
+				"id  name          bio                                       address.city\n" +
+				"--  ------------  ----------------------------------------  ------------\n" +
+				" 1  Ada           Wrote the first published algorithm for…  London\n" +
+				"12  Grace Hopper                                            New York\n",
+		},
+		{
+			name:   "selected columns",
+			cmdStr: "read " + ts.URL() + "/users as=table columns=address.city,id",
+			want: "" +
+				"address.city  id\n" +
+				"------------  --\n" +
+				"London         1\n" +
+				"New York      12\n",
+		},
+		{
+			name:    "fit to width",
+			cmdStr:  "read " + ts.URL() + "/users as=table columns=id,bio",
+			columns: "24",
+			want: "" +
+				"id  bio\n" +
+				"--  --------------------\n" +
+				" 1  Wrote the first pub…\n" +
+				"12\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLUMNS", tt.columns)
+			stdout, stderr, err := runCommand(t, tt.cmdStr)
+			if err != nil {
+				t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout =\n%s\nwant\n%s", stdout, tt.want)
+			}
+			if strings.Contains(stdout, "\x1b[") {
+				t.Errorf("expected plain text when stdout is not a terminal, got %q", stdout)
+			}
+		})
+	}
+
+	_, _, err := runCommand(t, "read "+ts.URL()+"/broken.csv as=table")
+	if execErr, ok := err.(*runtime.ExecutionError); !ok || execErr.Code != 9 {
+		t.Errorf("expected output error for invalid CSV, got %v", err)
+	}
+}
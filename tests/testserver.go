@@ -3,24 +3,44 @@ package tests
 
 import (
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"time"
+)
+
+// Client credentials accepted by the mock OAuth2 token endpoint.
+const (
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
 )
 
 // TestServer provides a test HTTP server with various endpoints.
 type TestServer struct {
 	server *httptest.Server
 	mux    *http.ServeMux
+
+	// Mock OAuth2 token endpoint state
+	mu            sync.Mutex
+	tokenLifetime time.Duration
+	tokenSeq      int
+	accessTokens  map[string]bool
+	refreshTokens map[string]bool
+	grants        []string // grant type of every token request, in order
 }
 
 // NewTestServer creates a new test server.
 func NewTestServer() *TestServer {
 	mux := http.NewServeMux()
 	ts := &TestServer{
-		mux: mux,
+		mux:           mux,
+		tokenLifetime: time.Hour,
+		accessTokens:  make(map[string]bool),
+		refreshTokens: make(map[string]bool),
 	}
 
 	// Echo endpoint - returns request details
@@ -55,7 +75,13 @@ func NewTestServer() *TestServer {
 	// Status endpoint - returns specific status code
 	mux.HandleFunc("/status/", ts.handleStatus)
 
-	ts.server = httptest.NewServer(mux)
+	// OAuth2 endpoints - issues tokens and checks bearer tokens
+	mux.HandleFunc("/oauth/token", ts.handleToken)
+	mux.HandleFunc("/oauth/protected", ts.handleProtected)
+
+	// Assign before starting so handlers can read ts.server without a race
+	ts.server = httptest.NewUnstartedServer(mux)
+	ts.server.Start()
 	return ts
 }
 
@@ -156,6 +182,121 @@ func (ts *TestServer) handleJSON(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, `{"message": "Hello, World!", "items": [1, 2, 3]}`)
 }
 
+// SetTokenLifetime sets the expires_in of tokens issued from now on.
+func (ts *TestServer) SetTokenLifetime(d time.Duration) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.tokenLifetime = d
+}
+
+// Grants returns the grant type of every token request so far.
+func (ts *TestServer) Grants() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.grants...)
+}
+
+// RevokeAccessTokens makes every issued access token invalid, as if they expired.
+func (ts *TestServer) RevokeAccessTokens() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.accessTokens = make(map[string]bool)
+}
+
+// handleToken is a mock OAuth2 token endpoint. It accepts the client_credentials
+// grant with Basic client authentication, the refresh_token grant (rotating the
+// refresh token), and a JSON username/password login that issues a refresh token.
+func (ts *TestServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	grant := "password"
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var login struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Username == "" || login.Password == "" {
+			ts.tokenError(w, http.StatusBadRequest, "invalid_request")
+			return
+		}
+	} else {
+		r.ParseForm()
+		grant = r.PostForm.Get("grant_type")
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.grants = append(ts.grants, grant)
+
+	issueRefresh := false
+	switch grant {
+	case "password":
+		issueRefresh = true
+	case "client_credentials":
+		if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+			ts.tokenError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+	case "refresh_token":
+		refresh := r.PostForm.Get("refresh_token")
+		if !ts.refreshTokens[refresh] {
+			ts.tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		delete(ts.refreshTokens, refresh)
+		issueRefresh = true
+	default:
+		ts.tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	ts.tokenSeq++
+	response := map[string]interface{}{
+		"access_token": fmt.Sprintf("access-%d", ts.tokenSeq),
+		"token_type":   "Bearer",
+		"expires_in":   int(ts.tokenLifetime / time.Second),
+	}
+	ts.accessTokens[response["access_token"].(string)] = true
+	if issueRefresh {
+		refresh := fmt.Sprintf("refresh-%d", ts.tokenSeq)
+		ts.refreshTokens[refresh] = true
+		response["refresh_token"] = refresh
+	}
+	if scope := r.PostForm.Get("scope"); scope != "" {
+		response["scope"] = scope
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleProtected requires a bearer token issued by handleToken.
+func (ts *TestServer) handleProtected(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	ts.mu.Lock()
+	valid := ts.accessTokens[token]
+	ts.mu.Unlock()
+	if !valid {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "invalid token")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "token:%s", token)
+}
+
+// tokenError writes an OAuth2 error response.
+func (ts *TestServer) tokenError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error": "%s"}`, code)
+}
+
 // handleStatus returns a specific HTTP status code.
 func (ts *TestServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	// Extract status code from path
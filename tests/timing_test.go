@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adammpkins/req/internal/parser"
+)
+
+// timingOutput mirrors the JSON line written by timing=json.
+type timingOutput struct {
+	Timing struct {
+		Hops []struct {
+			Method     string  `json:"method"`
+			URL        string  `json:"url"`
+			Status     int     `json:"status"`
+			DNSMs      float64 `json:"dns_ms"`
+			ConnectMs  float64 `json:"connect_ms"`
+			TLSMs      float64 `json:"tls_ms"`
+			TTFBMs     float64 `json:"ttfb_ms"`
+			TransferMs float64 `json:"transfer_ms"`
+			TotalMs    float64 `json:"total_ms"`
+			Reused     bool    `json:"reused"`
+		} `json:"hops"`
+		TotalMs float64 `json:"total_ms"`
+	} `json:"timing"`
+}
+
+// parseTimingLine finds and decodes the timing=json line in stderr.
+func parseTimingLine(t *testing.T, stderr string) timingOutput {
+	t.Helper()
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.HasPrefix(line, `{"timing":`) {
+			var out timingOutput
+			if err := json.Unmarshal([]byte(line), &out); err != nil {
+				t.Fatalf("invalid timing JSON %q: %v", line, err)
+			}
+			return out
+		}
+	}
+	t.Fatalf("no timing line in stderr:\n%s", stderr)
+	return timingOutput{}
+}
+
+// TestRequestTiming covers the timing flag across a redirect and over TLS.
+func TestRequestTiming(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.Redirect(w, r, "/slow", http.StatusFound)
+		case "/slow":
+			time.Sleep(30 * time.Millisecond)
+			fmt.Fprint(w, "done")
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	t.Run("redirect hops as JSON", func(t *testing.T) {
+		stdout, stderr, err := runCommand(t, "read "+server.URL+"/login timing=json")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if stdout != "done" {
+			t.Errorf("stdout = %q", stdout)
+		}
+		out := parseTimingLine(t, stderr)
+		hops := out.Timing.Hops
+		if len(hops) != 2 {
+			t.Fatalf("got %d hops, want 2: %+v", len(hops), hops)
+		}
+		if hops[0].Status != 302 || hops[0].URL != server.URL+"/login" || hops[0].Reused {
+			t.Errorf("first hop = %+v, want a 302 for /login on a new connection", hops[0])
+		}
+		if hops[1].Status != 200 || hops[1].URL != server.URL+"/slow" || !hops[1].Reused {
+			t.Errorf("second hop = %+v, want a 200 for /slow on the reused connection", hops[1])
+		}
+		if hops[1].TTFBMs < 30 {
+			t.Errorf("second hop ttfb = %.1fms, want at least the server's 30ms delay", hops[1].TTFBMs)
+		}
+		if hops[1].ConnectMs != 0 || hops[1].DNSMs != 0 {
+			t.Errorf("reused connection reported dns/connect time: %+v", hops[1])
+		}
+		if out.Timing.TotalMs < hops[0].TotalMs+hops[1].TotalMs {
+			t.Errorf("total %.1fms is less than the sum of the hops", out.Timing.TotalMs)
+		}
+	})
+
+	t.Run("text block", func(t *testing.T) {
+		_, stderr, err := runCommand(t, "read "+server.URL+"/login timing")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		for _, want := range []string{"Timing:", "302 GET " + server.URL + "/login", "200 GET " + server.URL + "/slow", "ttfb ", "reused connection", "over 2 hops"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr missing %q:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("TLS handshake", func(t *testing.T) {
+		tlsServer := httptest.NewTLSServer(handler)
+		defer tlsServer.Close()
+		_, stderr, err := runCommand(t, "read "+tlsServer.URL+"/slow insecure=true timing=json")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		hops := parseTimingLine(t, stderr).Timing.Hops
+		if len(hops) != 1 || hops[0].TLSMs <= 0 || hops[0].ConnectMs <= 0 {
+			t.Errorf("hops = %+v, want one hop with connect and TLS time", hops)
+		}
+	})
+
+	t.Run("no timing by default", func(t *testing.T) {
+		_, stderr, _ := runCommand(t, "read "+server.URL+"/slow")
+		if strings.Contains(stderr, "Timing") {
+			t.Errorf("timing printed without the flag:\n%s", stderr)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		if _, err := parser.Parse("read https://example.com timing=xml"); err == nil {
+			t.Error("Parse() expected error for timing=xml")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adammpkins/req/internal/parser"
+	"github.com/adammpkins/req/internal/session"
+)
+
+// TestVerboseExchange covers the verbose request/response dump across redirects,
+// credential redaction and verbose=unredacted.
+func TestVerboseExchange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "cookie-secret", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/final?step=2", http.StatusFound)
+		case "/final":
+			w.Header().Set("X-Served-By", "final")
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	t.Run("redirect hops redacted", func(t *testing.T) {
+		stdout, stderr, err := runCommand(t, "read "+server.URL+"/start include='header: Authorization: Bearer token-secret' verbose")
+		if err != nil {
+			t.Fatalf("error = %v\nstderr: %s", err, stderr)
+		}
+		if stdout != "ok" {
+			t.Errorf("stdout = %q", stdout)
+		}
+		for _, want := range []string{
+			"> GET /start\n> Host: " + host + "\n",
+			"> Authorization: Bearer ***\n",
+			"< HTTP/1.1 302 Found\n",
+			"< Set-Cookie: sid=***; Path=/; HttpOnly\n",
+			"> GET /final?step=2\n",
+			"> Cookie: sid=***\n",
+			"< HTTP/1.1 200 OK\n",
+			"< X-Served-By: final\n",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr missing %q:\n%s", want, stderr)
+			}
+		}
+		for _, secret := range []string{"token-secret", "cookie-secret"} {
+			if strings.Contains(stderr, secret) {
+				t.Errorf("stderr leaks %q:\n%s", secret, stderr)
+			}
+		}
+	})
+
+	t.Run("unredacted", func(t *testing.T) {
+		_, stderr, err := runCommand(t, "read "+server.URL+"/start include='header: Authorization: Bearer token-secret' verbose=unredacted")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		for _, want := range []string{"> Authorization: Bearer token-secret\n", "< Set-Cookie: sid=cookie-secret; Path=/; HttpOnly\n", "> Cookie: sid=cookie-secret\n"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr missing %q:\n%s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("session credentials", func(t *testing.T) {
+		sessionHost, _ := session.ExtractHost(server.URL)
+		if err := session.SaveSession(&session.Session{Host: sessionHost, Authorization: "Bearer session-secret"}); err != nil {
+			t.Fatal(err)
+		}
+		defer session.DeleteSession(sessionHost)
+
+		_, stderr, err := runCommand(t, "read "+server.URL+"/final verbose")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stderr, "> Authorization: Bearer ***\n") || strings.Contains(stderr, "session-secret") {
+			t.Errorf("session Authorization not shown redacted:\n%s", stderr)
+		}
+	})
+
+	t.Run("request bodies", func(t *testing.T) {
+		_, stderr, err := runCommand(t, "send "+server.URL+`/final with='{"name":"req"}' verbose`)
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stderr, ">\n{\"name\":\"req\"}\n") {
+			t.Errorf("stderr missing JSON body:\n%s", stderr)
+		}
+
+		_, stderr, err = runCommand(t, "send "+server.URL+"/final include='header: Content-Type: application/x-www-form-urlencoded' with='grant_type=password&username=ada&password=hunter2&client_secret=s3cret' verbose")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stderr, ">\ngrant_type=password&username=ada&password=***&client_secret=***\n") {
+			t.Errorf("stderr missing redacted form body:\n%s", stderr)
+		}
+
+		_, stderr, err = runCommand(t, "send "+server.URL+`/final with='{"user":{"name":"ada","password":"hunter2"}}' verbose`)
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stderr, `{"user":{"name":"ada","password":"***"}}`) {
+			t.Errorf("stderr missing redacted JSON body:\n%s", stderr)
+		}
+
+		long := strings.Repeat("a", 5000)
+		_, stderr, err = runCommand(t, "send "+server.URL+"/final with="+long+" verbose")
+		if err != nil {
+			t.Fatalf("error = %v", err)
+		}
+		if !strings.Contains(stderr, "[truncated, 5000 bytes]") || strings.Contains(stderr, strings.Repeat("a", 2049)) {
+			t.Errorf("long body not truncated:\n%.300s", stderr)
+		}
+	})
+
+	t.Run("quiet without verbose", func(t *testing.T) {
+		_, stderr, _ := runCommand(t, "read "+server.URL+"/start")
+		if strings.Contains(stderr, "> GET") {
+			t.Errorf("exchange dumped without verbose:\n%s", stderr)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		if _, err := parser.Parse("read https://example.com verbose=all"); err == nil {
+			t.Error("Parse() expected error for verbose=all")
+		}
+	})
+}
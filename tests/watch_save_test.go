@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/adammpkins/req/internal/parser"
@@ -11,9 +15,7 @@ import (
 	"github.com/adammpkins/req/internal/runtime"
 )
 
-// TestSaveStreaming tests that save verb uses efficient file writing.
-// Note: Current implementation uses io.Copy which is efficient, but reads body into memory first.
-// Future optimization: stream directly from resp.Body when no expect checks are present.
+// TestSaveStreaming tests that save verb streams the body to the destination file.
 func TestSaveStreaming(t *testing.T) {
 	ts := NewTestServer()
 	defer ts.Close()
@@ -63,9 +65,212 @@ func TestSaveStreaming(t *testing.T) {
 	}
 }
 
-// TestWatchTTYDetection tests TTY detection for watch verb.
-// Note: Watch verb TTY detection is not yet implemented.
+// TestSaveAtomic tests that failed saves leave an existing destination untouched
+// and do not leave temporary files behind.
+func TestSaveAtomic(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(dest, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cmdStr  string
+		content string
+	}{
+		{"http error", "save " + ts.URL() + "/status/500 to=" + dest, "previous"},
+		{"expect failure", "save " + ts.URL() + "/json to=" + dest + ` expect=contains:"missing"`, "previous"},
+		{"expect on saved body", "save " + ts.URL() + "/json to=" + dest + ` expect=status:200,contains:"Hello"`, `{"message": "Hello, World!", "items": [1, 2, 3]}`},
+		{"decompressed", "save " + ts.URL() + "/gzip to=" + dest, "This is gzipped content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, _ := runCommand(t, tt.cmdStr)
+			if !strings.Contains(stderr, "URL: "+ts.URL()) {
+				t.Errorf("metadata not printed:\n%s", stderr)
+			}
+
+			data, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatalf("Failed to read destination: %v", err)
+			}
+			if string(data) != tt.content {
+				t.Errorf("destination = %q, want %q", data, tt.content)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				t.Errorf("expected only the destination file, found %d entries", len(entries))
+			}
+		})
+	}
+}
+
+// TestWatchTTYDetection tests that watch prints raw event data lines when stdout is not a TTY.
 func TestWatchTTYDetection(t *testing.T) {
-	t.Skip("Watch verb TTY detection not yet implemented")
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Last-Event-ID") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 10\n: keep-alive\n\nid: 1\ndata: {\"step\":1}\n\nevent: done\ndata: line one\ndata: line two\n\n")
+	})
+
+	output, _, err := runCommand(t, "watch "+ts.URL()+"/stream under=5s")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	expected := "{\"step\":1}\nline one\nline two\n"
+	if output != expected {
+		t.Errorf("Expected raw event lines %q, got %q", expected, output)
+	}
 }
 
+// TestWatchSSEReconnect tests that watch reconnects with Last-Event-ID after the stream drops.
+func TestWatchSSEReconnect(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+	ts.mux.HandleFunc("/deploy", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		mu.Unlock()
+		switch r.Header.Get("Last-Event-ID") {
+		case "":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: building\n\nid: 2\ndata: testing\n\n")
+		case "2":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 3\r\ndata: deployed\r\n\r\n")
+		default:
+			// Tell the client to stop reconnecting
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	output, stderr, err := runCommand(t, "watch "+ts.URL()+"/deploy under=5s")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if output != "building\ntesting\ndeployed\n" {
+		t.Errorf("Unexpected event output: %q", output)
+	}
+	if len(lastEventIDs) != 3 || lastEventIDs[1] != "2" || lastEventIDs[2] != "3" {
+		t.Errorf("Expected reconnects with Last-Event-ID 2 then 3, got %q", lastEventIDs)
+	}
+	if !strings.Contains(stderr, "Last-Event-ID: 2") {
+		t.Errorf("Expected reconnect note in stderr, got: %s", stderr)
+	}
+}
+
+// TestWatchPollingUntil tests that polling prints only changed responses and stops once until= matches.
+func TestWatchPollingUntil(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	var mu sync.Mutex
+	polls := 0
+	ts.mux.HandleFunc("/job", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		polls++
+		n := polls
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if n < 3 {
+			fmt.Fprint(w, `{"state":"running"}`)
+			return
+		}
+		fmt.Fprint(w, `{"state":"succeeded"}`)
+	})
+
+	output, stderr, err := runCommand(t, "watch "+ts.URL()+"/job every=10ms until=jsonpath:$.state==succeeded under=5s")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	expected := "{\"state\":\"running\"}\n{\"state\":\"succeeded\"}\n"
+	if output != expected {
+		t.Errorf("Expected only changed responses %q, got %q", expected, output)
+	}
+	if !strings.Contains(stderr, "Condition met after 3 poll(s)") {
+		t.Errorf("Expected condition note in stderr, got: %s", stderr)
+	}
+}
+
+// TestWatchPollingDeadline tests that an unmet until= predicate fails with exit code 6.
+func TestWatchPollingDeadline(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	_, _, err := runCommand(t, "watch "+ts.URL()+"/status/503 every=10ms until=status:200 under=100ms")
+
+	execErr, ok := err.(*runtime.ExecutionError)
+	if !ok {
+		t.Fatalf("Expected ExecutionError, got %v", err)
+	}
+	if execErr.Code != 6 {
+		t.Errorf("Expected exit code 6, got %d (%s)", execErr.Code, execErr.Message)
+	}
+}
+
+// runCommand executes a command and returns captured stdout, stderr, and the execution error.
+func runCommand(t *testing.T, cmdStr string) (string, string, error) {
+	t.Helper()
+
+	cmd, err := parser.Parse(cmdStr)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	plan, err := planner.Plan(cmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	executor, err := runtime.NewExecutor(plan)
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	done := make(chan bool)
+	go func() {
+		stdoutBuf.ReadFrom(outR)
+		done <- true
+	}()
+	go func() {
+		stderrBuf.ReadFrom(errR)
+		done <- true
+	}()
+
+	err = executor.Execute(plan)
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	<-done
+	<-done
+
+	return stdoutBuf.String(), stderrBuf.String(), err
+}
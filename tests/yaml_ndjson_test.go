@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestYAMLAndNDJSONOutput tests as=yaml and as=ndjson, alone and with pick=.
+func TestYAMLAndNDJSONOutput(t *testing.T) {
+	ts := NewTestServer()
+	defer ts.Close()
+
+	ts.mux.HandleFunc("/deploy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "web", "replicas": 3, "id": 9007199254740993,
+			"labels": {"tier": "frontend", "app": "web"},
+			"ports": [{"port": 80, "name": "http"}, {"port": 443}],
+			"args": ["--verbose", "yes", "1.0", "a: b", "", "<tag>", "line\nbreak"],
+			"paused": false, "owner": null, "env": {}, "volumes": [], "nested": [[1, 2], []]}`))
+	})
+	ts.mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte("{\"b\": 1, \"a\": 2}\n{\n  \"b\": 3\n}\n"))
+	})
+
+	tests := []struct {
+		name   string
+		cmdStr string
+		want   string
+	}{
+		{
+			name:   "yaml keeps key order and quotes ambiguous strings",
+			cmdStr: "read " + ts.URL() + "/deploy as=yaml",
+			want: `name: web
+replicas: 3
+id: 9007199254740993
+labels:
+  tier: frontend
+  app: web
+ports:
+  - port: 80
+    name: http
+  - port: 443
+args:
+  - "--verbose"
+  - "yes"
+  - "1.0"
+  - "a: b"
+  - ""
+  - "<tag>"
+  - "line\nbreak"
+paused: false
+owner: null
+env: {}
+volumes: []
+nested:
+  - - 1
+    - 2
+  - []
+`,
+		},
+		{
+			name:   "yaml with pick",
+			cmdStr: "read " + ts.URL() + "/deploy pick=$.ports as=yaml",
+			want:   "- port: 80\n  name: http\n- port: 443\n",
+		},
+		{
+			name:   "json keeps key order",
+			cmdStr: "read " + ts.URL() + "/deploy pick=$.labels as=json",
+			want:   "{\n  \"tier\": \"frontend\",\n  \"app\": \"web\"\n}\n",
+		},
+		{
+			name:   "ndjson splits arrays",
+			cmdStr: "read " + ts.URL() + "/deploy pick=$.ports as=ndjson",
+			want:   "{\"port\":80,\"name\":\"http\"}\n{\"port\":443}\n",
+		},
+		{
+			name:   "ndjson with a filter",
+			cmdStr: "read " + ts.URL() + "/deploy pick=$.args[?(@=~/^[<a]/)] as=ndjson",
+			want:   "\"a: b\"\n\"<tag>\"\n",
+		},
+		{
+			name:   "ndjson object is one line",
+			cmdStr: "read " + ts.URL() + "/deploy pick=$.labels as=ndjson",
+			want:   "{\"tier\":\"frontend\",\"app\":\"web\"}\n",
+		},
+		{
+			name:   "ndjson stream is compacted",
+			cmdStr: "read " + ts.URL() + "/events as=ndjson",
+			want:   "{\"b\":1,\"a\":2}\n{\"b\":3}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, tt.cmdStr)
+			if err != nil {
+				t.Fatalf("Execute() error = %v\nstderr: %s", err, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("stdout =\n%s\nwant\n%s", stdout, tt.want)
+			}
+		})
+	}
+}